@@ -0,0 +1,116 @@
+package apdex
+
+import "time"
+
+// Accumulator incrementally counts requests into a model's buckets, so
+// that a Summary can be taken at any time without keeping individual
+// latencies. It isn't safe for concurrent use.
+type Accumulator struct {
+	model Model
+
+	// Requests per bucket, with a final bucket for requests slower than
+	// the model's last bucket.
+	counts []int64
+	errors int64
+}
+
+func NewAccumulator(model Model) *Accumulator {
+	return &Accumulator{
+		model:  model,
+		counts: make([]int64, len(model.Buckets)+1),
+	}
+}
+
+// Add records a successful request.
+func (a *Accumulator) Add(d time.Duration) {
+	a.counts[a.model.bucket(d)]++
+}
+
+// AddError records a failed request, which is treated as frustrated.
+func (a *Accumulator) AddError() {
+	a.errors++
+}
+
+// Merge adds the requests recorded by other, which must use the same
+// model, to a.
+func (a *Accumulator) Merge(other *Accumulator) {
+	for i, c := range other.counts {
+		a.counts[i] += c
+	}
+	a.errors += other.errors
+}
+
+// Snapshot returns a summary of the requests recorded.
+func (a *Accumulator) Snapshot() Summary {
+	var s Summary
+	for i, c := range a.counts {
+		var weight float64
+		if i < len(a.model.Buckets) {
+			weight = a.model.Buckets[i].Weight
+		}
+		s.addN(weight, c)
+	}
+
+	s.Frustrated += a.errors
+	s.Errors = a.errors
+
+	return s
+}
+
+// Reset removes all recorded requests.
+func (a *Accumulator) Reset() {
+	clear(a.counts)
+	a.errors = 0
+}
+
+// SlidingWindow keeps an Accumulator for each of a fixed number of
+// intervals (e.g. seconds), so that summaries over any number of the
+// most recent intervals can be taken. It isn't safe for concurrent use.
+type SlidingWindow struct {
+	intervals []*Accumulator
+	head      int
+}
+
+// NewSlidingWindow returns a SlidingWindow covering the given number of
+// intervals.
+func NewSlidingWindow(model Model, intervals int) *SlidingWindow {
+	w := SlidingWindow{
+		intervals: make([]*Accumulator, intervals+1),
+	}
+
+	for i := range w.intervals {
+		w.intervals[i] = NewAccumulator(model)
+	}
+
+	return &w
+}
+
+// Add records a successful request in the current interval.
+func (w *SlidingWindow) Add(d time.Duration) {
+	w.intervals[w.head].Add(d)
+}
+
+// AddError records a failed request in the current interval.
+func (w *SlidingWindow) AddError() {
+	w.intervals[w.head].AddError()
+}
+
+// Advance completes the current interval and starts a new one, dropping
+// the oldest.
+func (w *SlidingWindow) Advance() {
+	w.head = (w.head + 1) % len(w.intervals)
+	w.intervals[w.head].Reset()
+}
+
+// Snapshot returns a summary of the requests recorded in the most recent
+// n completed intervals.
+func (w *SlidingWindow) Snapshot(n int) Summary {
+	n = min(n, len(w.intervals)-1)
+
+	var s Summary
+	for i := 1; i <= n; i++ {
+		s.Merge(w.intervals[(w.head-i+len(w.intervals))%len(w.intervals)].Snapshot())
+	}
+
+	return s
+}
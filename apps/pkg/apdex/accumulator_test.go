@@ -0,0 +1,83 @@
+package apdex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccumulator(t *testing.T) {
+	m := Tiered()
+	latencies := []time.Duration{
+		10 * time.Millisecond,
+		30 * time.Millisecond,
+		60 * time.Millisecond,
+		90 * time.Millisecond,
+		200 * time.Millisecond,
+	}
+
+	a := NewAccumulator(m)
+	for _, d := range latencies {
+		a.Add(d)
+	}
+	a.AddError()
+
+	got := a.Snapshot()
+	assert.Equal(t, int64(1), got.Satisfied)
+	assert.Equal(t, int64(3), got.Tolerating)
+	assert.Equal(t, int64(2), got.Frustrated)
+	assert.Equal(t, int64(1), got.Errors)
+	assert.InDelta(t, 2.8/6, got.Score(), 1e-9)
+
+	b := NewAccumulator(m)
+	b.Add(time.Millisecond)
+	b.Merge(a)
+	assert.Equal(t, int64(7), b.Snapshot().Samples())
+	assert.Equal(t, int64(2), b.Snapshot().Satisfied)
+
+	a.Reset()
+	assert.Equal(t, Summary{}, a.Snapshot())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	w := NewSlidingWindow(Standard(50*time.Millisecond), 3)
+
+	// Interval 1: 1 satisfied.
+	w.Add(10 * time.Millisecond)
+	w.Advance()
+
+	// Interval 2: 1 tolerating.
+	w.Add(100 * time.Millisecond)
+	w.Advance()
+
+	// Interval 3: 1 failed.
+	w.AddError()
+	w.Advance()
+
+	// Current interval isn't included until it's complete.
+	w.Add(10 * time.Millisecond)
+
+	assert.Equal(t, Summary{Frustrated: 1, Errors: 1}, w.Snapshot(1))
+	assert.Equal(t, Summary{Tolerating: 1, Frustrated: 1, Errors: 1, Total: 0.5}, w.Snapshot(2))
+	assert.Equal(t, Summary{Satisfied: 1, Tolerating: 1, Frustrated: 1, Errors: 1, Total: 1.5}, w.Snapshot(3))
+
+	// Asking for more intervals than are kept returns them all.
+	assert.Equal(t, w.Snapshot(3), w.Snapshot(10))
+
+	// Interval 1 drops out of the window.
+	w.Advance()
+	assert.Equal(t, Summary{Satisfied: 1, Tolerating: 1, Frustrated: 1, Errors: 1, Total: 1.5}, w.Snapshot(3))
+	w.Advance()
+	assert.Equal(t, Summary{Satisfied: 1, Frustrated: 1, Errors: 1, Total: 1}, w.Snapshot(3))
+}
+
+func TestAccumulatorTruncatesTieredLatencies(t *testing.T) {
+	a := NewAccumulator(Tiered())
+	a.Add(20500 * time.Microsecond)
+	a.Add(50500 * time.Microsecond)
+
+	got := a.Snapshot()
+	assert.Equal(t, int64(1), got.Satisfied)
+	assert.Equal(t, int64(1), got.Tolerating)
+}
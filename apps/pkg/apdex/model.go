@@ -0,0 +1,189 @@
+package apdex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModelStandard = "standard"
+	ModelTiered   = "tiered"
+	ModelCustom   = "custom"
+)
+
+// Bucket gives requests taking up to (and including) Max a score of
+// Weight.
+type Bucket struct {
+	Max    time.Duration
+	Weight float64
+}
+
+// Model scores requests by the first bucket their latency falls into.
+// Requests slower than the last bucket score zero.
+type Model struct {
+	Name    string
+	Buckets []Bucket
+
+	// Resolution, if set, truncates latencies before they're compared
+	// with the buckets.
+	Resolution time.Duration
+}
+
+// Standard returns the standard Apdex model, where requests taking up
+// to t are satisfied, up to 4t are tolerating and slower requests are
+// frustrated.
+func Standard(t time.Duration) Model {
+	return Model{
+		Name: ModelStandard,
+		Buckets: []Bucket{
+			{Max: t, Weight: 1},
+			{Max: t * 4, Weight: 0.5},
+		},
+	}
+}
+
+// Tiered returns a model that scores requests in 20ms, 50ms, 75ms and
+// 100ms tiers, as described in the README. Latencies are compared in
+// whole milliseconds, so a request taking 20.9ms is in the 20ms tier.
+func Tiered() Model {
+	return Model{
+		Name:       ModelTiered,
+		Resolution: time.Millisecond,
+		Buckets: []Bucket{
+			{Max: time.Millisecond * 20, Weight: 1},
+			{Max: time.Millisecond * 50, Weight: 0.8},
+			{Max: time.Millisecond * 75, Weight: 0.6},
+			{Max: time.Millisecond * 100, Weight: 0.4},
+		},
+	}
+}
+
+// Custom returns a model for the given buckets, which must be in
+// ascending order of latency and have weights between 0 and 1.
+func Custom(buckets []Bucket) (Model, error) {
+	if len(buckets) == 0 {
+		return Model{}, fmt.Errorf("missing buckets")
+	}
+
+	for i, b := range buckets {
+		if b.Max <= 0 {
+			return Model{}, fmt.Errorf("bucket %d: latency must be positive", i+1)
+		}
+
+		if b.Weight < 0 || b.Weight > 1 {
+			return Model{}, fmt.Errorf("bucket %d: weight must be between 0 and 1", i+1)
+		}
+
+		if i > 0 && b.Max <= buckets[i-1].Max {
+			return Model{}, fmt.Errorf("bucket %d: latencies must be in ascending order", i+1)
+		}
+	}
+
+	return Model{Name: ModelCustom, Buckets: buckets}, nil
+}
+
+// ParseModel returns the named model. t is the threshold of the standard
+// model and buckets are the buckets of the custom model, in the form
+// "20ms=1,50ms=0.5".
+func ParseModel(name string, t time.Duration, buckets string) (Model, error) {
+	switch strings.ToLower(name) {
+	case ModelStandard:
+		if t <= 0 {
+			return Model{}, fmt.Errorf("threshold must be positive")
+		}
+		return Standard(t), nil
+
+	case ModelTiered:
+		return Tiered(), nil
+
+	case ModelCustom:
+		parsed, err := ParseBuckets(buckets)
+		if err != nil {
+			return Model{}, fmt.Errorf("parsing buckets: %w", err)
+		}
+		return Custom(parsed)
+
+	default:
+		return Model{}, fmt.Errorf("invalid model: %q", name)
+	}
+}
+
+// ParseBuckets parses buckets in the form "20ms=1,50ms=0.5".
+func ParseBuckets(s string) ([]Bucket, error) {
+	var buckets []Bucket
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		latency, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid bucket %q, expected latency=weight", part)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(latency))
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency in %q: %w", part, err)
+		}
+
+		w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight in %q: %w", part, err)
+		}
+
+		buckets = append(buckets, Bucket{Max: d, Weight: w})
+	}
+
+	return buckets, nil
+}
+
+// Weight returns the score of a single request.
+func (m Model) Weight(d time.Duration) float64 {
+	if i := m.bucket(d); i < len(m.Buckets) {
+		return m.Buckets[i].Weight
+	}
+
+	return 0
+}
+
+// bucket returns the index of the bucket a latency falls into, or the
+// number of buckets if it's slower than all of them.
+func (m Model) bucket(d time.Duration) int {
+	if m.Resolution > 0 {
+		d = d.Truncate(m.Resolution)
+	}
+
+	for i, b := range m.Buckets {
+		if d <= b.Max {
+			return i
+		}
+	}
+
+	return len(m.Buckets)
+}
+
+// Score returns the mean score of the given latencies.
+func (m Model) Score(latencies []time.Duration) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, d := range latencies {
+		total += m.Weight(d)
+	}
+
+	return total / float64(len(latencies))
+}
+
+func (m Model) String() string {
+	parts := make([]string, len(m.Buckets))
+	for i, b := range m.Buckets {
+		parts[i] = fmt.Sprintf("%s=%g", b.Max, b.Weight)
+	}
+
+	return fmt.Sprintf("%s (%s)", m.Name, strings.Join(parts, ", "))
+}
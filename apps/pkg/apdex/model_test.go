@@ -0,0 +1,112 @@
+package apdex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandard(t *testing.T) {
+	m := Standard(50 * time.Millisecond)
+
+	tests := []struct {
+		name    string
+		latency time.Duration
+		want    float64
+	}{
+		{name: "satisfied", latency: 10 * time.Millisecond, want: 1},
+		{name: "satisfied at T", latency: 50 * time.Millisecond, want: 1},
+		{name: "tolerating", latency: 51 * time.Millisecond, want: 0.5},
+		{name: "tolerating at 4T", latency: 200 * time.Millisecond, want: 0.5},
+		{name: "frustrated", latency: 201 * time.Millisecond, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.Weight(tt.latency))
+		})
+	}
+
+	// (satisfied + tolerating / 2) / total
+	latencies := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond, time.Second}
+	assert.InDelta(t, 0.625, m.Score(latencies), 1e-9)
+}
+
+func TestParseModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		threshold time.Duration
+		buckets   string
+		want      Model
+		wantErr   string
+	}{
+		{
+			name:      "standard",
+			model:     "standard",
+			threshold: 100 * time.Millisecond,
+			want:      Standard(100 * time.Millisecond),
+		},
+		{
+			name:    "standard without threshold",
+			model:   "standard",
+			wantErr: "threshold must be positive",
+		},
+		{
+			name:  "tiered",
+			model: "TIERED",
+			want:  Tiered(),
+		},
+		{
+			name:    "custom",
+			model:   "custom",
+			buckets: "10ms=1, 1s=0.25",
+			want: Model{Name: ModelCustom, Buckets: []Bucket{
+				{Max: 10 * time.Millisecond, Weight: 1},
+				{Max: time.Second, Weight: 0.25},
+			}},
+		},
+		{
+			name:    "custom without buckets",
+			model:   "custom",
+			wantErr: "missing buckets",
+		},
+		{
+			name:    "custom out of order",
+			model:   "custom",
+			buckets: "50ms=1,20ms=0.5",
+			wantErr: "bucket 2: latencies must be in ascending order",
+		},
+		{
+			name:    "custom weight out of range",
+			model:   "custom",
+			buckets: "50ms=2",
+			wantErr: "bucket 1: weight must be between 0 and 1",
+		},
+		{
+			name:    "custom malformed",
+			model:   "custom",
+			buckets: "50ms",
+			wantErr: `parsing buckets: invalid bucket "50ms", expected latency=weight`,
+		},
+		{
+			name:    "unknown",
+			model:   "linear",
+			wantErr: `invalid model: "linear"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModel(tt.model, tt.threshold, tt.buckets)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
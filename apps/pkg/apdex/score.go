@@ -2,27 +2,24 @@ package apdex
 
 import "time"
 
+// Score returns the mean score of the given latencies using the tiered
+// model.
 func Score(latencies []time.Duration) float64 {
-	if len(latencies) == 0 {
-		return 0
-	}
+	return Tiered().Score(latencies)
+}
 
-	var total float64
-	for _, d := range latencies {
-		ms := d.Milliseconds()
-		switch {
-		case ms <= 20:
-			total += 1.0
-		case ms <= 50:
-			total += 0.8
-		case ms <= 75:
-			total += 0.6
-		case ms <= 100:
-			total += 0.4
-		default:
-			total += 0.0
-		}
+// Grade returns the rating for a score, as described in the README.
+func Grade(score float64) string {
+	switch {
+	case score >= 0.94:
+		return "Excellent"
+	case score >= 0.85:
+		return "Good"
+	case score >= 0.70:
+		return "Fair"
+	case score >= 0.50:
+		return "Poor"
+	default:
+		return "Unacceptable"
 	}
-
-	return total / float64(len(latencies))
 }
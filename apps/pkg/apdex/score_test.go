@@ -39,6 +39,16 @@ func TestScore(t *testing.T) {
 			latencies: []time.Duration{76 * time.Millisecond, 90 * time.Millisecond, 100 * time.Millisecond},
 			want:      0.4,
 		},
+		{
+			name:      "fractional milliseconds are truncated",
+			latencies: []time.Duration{20500 * time.Microsecond, 20999 * time.Microsecond},
+			want:      1.0,
+		},
+		{
+			name:      "fractional milliseconds at 50ms are truncated",
+			latencies: []time.Duration{50500 * time.Microsecond},
+			want:      0.8,
+		},
 		{
 			name:      "all too slow (>100ms)",
 			latencies: []time.Duration{101 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond},
@@ -64,3 +74,27 @@ func TestScore(t *testing.T) {
 		})
 	}
 }
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{score: 1.0, want: "Excellent"},
+		{score: 0.94, want: "Excellent"},
+		{score: 0.93, want: "Good"},
+		{score: 0.85, want: "Good"},
+		{score: 0.84, want: "Fair"},
+		{score: 0.70, want: "Fair"},
+		{score: 0.69, want: "Poor"},
+		{score: 0.50, want: "Poor"},
+		{score: 0.49, want: "Unacceptable"},
+		{score: 0, want: "Unacceptable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Grade(tt.score))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package apdex
+
+// Summary counts requests by how they were scored. Requests scoring 1
+// are satisfied, requests scoring 0 (including failed requests) are
+// frustrated and anything in between is tolerating. Total is the sum of
+// the individual scores, so that models with more than one tolerating
+// bucket can be summarised without losing precision. Errors is the
+// number of frustrated requests that failed.
+type Summary struct {
+	Satisfied  int64   `json:"satisfied"`
+	Tolerating int64   `json:"tolerating"`
+	Frustrated int64   `json:"frustrated"`
+	Errors     int64   `json:"errors"`
+	Total      float64 `json:"total"`
+}
+
+// addN records n requests with the same score.
+func (s *Summary) addN(weight float64, n int64) {
+	switch {
+	case weight >= 1:
+		s.Satisfied += n
+	case weight <= 0:
+		s.Frustrated += n
+	default:
+		s.Tolerating += n
+	}
+	s.Total += weight * float64(n)
+}
+
+// Samples returns the number of requests summarised.
+func (s Summary) Samples() int64 {
+	return s.Satisfied + s.Tolerating + s.Frustrated
+}
+
+// ErrorRate returns the proportion of requests summarised that failed.
+func (s Summary) ErrorRate() float64 {
+	samples := s.Samples()
+	if samples == 0 {
+		return 0
+	}
+
+	return float64(s.Errors) / float64(samples)
+}
+
+// Score returns the mean score of the requests summarised.
+func (s Summary) Score() float64 {
+	samples := s.Samples()
+	if samples == 0 {
+		return 0
+	}
+
+	return s.Total / float64(samples)
+}
+
+// Merge adds the requests summarised by other to s.
+func (s *Summary) Merge(other Summary) {
+	s.Satisfied += other.Satisfied
+	s.Tolerating += other.Tolerating
+	s.Frustrated += other.Frustrated
+	s.Errors += other.Errors
+	s.Total += other.Total
+}
+
+// Aggregate combines summaries (e.g. from each region) into one, so that
+// its score is weighted by the number of requests behind each summary,
+// rather than being an average of averages.
+func Aggregate(summaries ...Summary) Summary {
+	var out Summary
+	for _, s := range summaries {
+		out.Merge(s)
+	}
+
+	return out
+}
@@ -0,0 +1,68 @@
+package apdex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	a := NewAccumulator(Standard(50 * time.Millisecond))
+	for _, d := range []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, time.Second} {
+		a.Add(d)
+	}
+	a.AddError()
+
+	got := a.Snapshot()
+
+	assert.Equal(t, Summary{Satisfied: 1, Tolerating: 1, Frustrated: 2, Errors: 1, Total: 1.5}, got)
+	assert.Equal(t, int64(4), got.Samples())
+	assert.Equal(t, 0.375, got.Score())
+	assert.Equal(t, 0.25, got.ErrorRate())
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name      string
+		summaries []Summary
+		want      float64
+	}{
+		{
+			name: "no summaries",
+			want: 0,
+		},
+		{
+			name: "empty summaries",
+			summaries: []Summary{
+				{},
+				{},
+			},
+			want: 0,
+		},
+		{
+			name: "weighted by requests",
+			summaries: []Summary{
+				// 90 requests scoring 1.
+				{Satisfied: 90, Total: 90},
+				// 10 requests scoring 0.
+				{Frustrated: 10},
+			},
+			want: 0.9,
+		},
+		{
+			name: "region without traffic",
+			summaries: []Summary{
+				{Satisfied: 3, Tolerating: 2, Total: 4},
+				{},
+			},
+			want: 0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, Aggregate(tt.summaries...).Score(), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// subBucketBits sets the precision of the histogram. Each power of two
+	// is split into 2^(subBucketBits-1) buckets, keeping the relative error
+	// of any recorded value below 1/64.
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+)
+
+// Histogram records latencies at microsecond resolution into log-linear
+// buckets, in the style of an HDR histogram, so that percentiles can be
+// read without keeping every sample. It isn't safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	count  int64
+	max    time.Duration
+}
+
+func New() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a latency to the histogram.
+func (h *Histogram) Record(d time.Duration) {
+	idx := bucketIndex(uint64(max(d.Microseconds(), 0)))
+	if idx >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, idx-len(h.counts)+1)...)
+	}
+
+	h.counts[idx]++
+	h.count++
+	h.max = max(h.max, d)
+}
+
+// Count returns the number of recorded latencies.
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+// Max returns the largest recorded latency.
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Quantile returns the latency at or below which q (between 0 and 1) of
+// recorded latencies fall.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	target := max(int64(math.Ceil(q*float64(h.count))), 1)
+
+	var seen int64
+	for idx, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return min(time.Duration(bucketUpper(idx))*time.Microsecond, h.max)
+		}
+	}
+
+	return h.max
+}
+
+// Merge adds the latencies recorded by other to h.
+func (h *Histogram) Merge(other *Histogram) {
+	if len(other.counts) > len(h.counts) {
+		h.counts = append(h.counts, make([]int64, len(other.counts)-len(h.counts))...)
+	}
+
+	for idx, c := range other.counts {
+		h.counts[idx] += c
+	}
+	h.count += other.count
+	h.max = max(h.max, other.max)
+}
+
+// Reset removes all recorded latencies.
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.count = 0
+	h.max = 0
+}
+
+// Percentiles are the commonly reported percentiles of a histogram, in
+// milliseconds.
+type Percentiles struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	P999  float64 `json:"p99_9_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+func (h *Histogram) Percentiles() Percentiles {
+	return Percentiles{
+		Count: h.count,
+		P50:   milliseconds(h.Quantile(0.5)),
+		P90:   milliseconds(h.Quantile(0.9)),
+		P95:   milliseconds(h.Quantile(0.95)),
+		P99:   milliseconds(h.Quantile(0.99)),
+		P999:  milliseconds(h.Quantile(0.999)),
+		Max:   milliseconds(h.max),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// bucketIndex returns the bucket for a value. Values below
+// subBucketCount have a bucket each; above that, each power of two is
+// split into subBucketHalf buckets.
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+
+	shift := bits.Len64(v) - subBucketBits
+	top := v >> shift
+
+	return subBucketCount + (shift-1)*subBucketHalf + int(top-subBucketHalf)
+}
+
+// bucketUpper returns the largest value that falls into a bucket.
+func bucketUpper(idx int) uint64 {
+	if idx < subBucketCount {
+		return uint64(idx)
+	}
+
+	j := idx - subBucketCount
+	shift := j/subBucketHalf + 1
+	top := uint64(j%subBucketHalf + subBucketHalf)
+
+	return (top+1)<<shift - 1
+}
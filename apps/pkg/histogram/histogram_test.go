@@ -0,0 +1,64 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuckets(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 129, 255, 256, 1000, 123456, 1_000_000} {
+		idx := bucketIndex(v)
+		assert.GreaterOrEqual(t, bucketUpper(idx), v, "value %d", v)
+		if idx > 0 {
+			assert.Less(t, bucketUpper(idx-1), v, "value %d", v)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	h := New()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{q: 0.5, want: 500 * time.Millisecond},
+		{q: 0.9, want: 900 * time.Millisecond},
+		{q: 0.99, want: 990 * time.Millisecond},
+		{q: 1, want: 1000 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		got := h.Quantile(tt.q)
+		assert.InEpsilon(t, tt.want, got, 1.0/64, "q %v", tt.q)
+	}
+
+	assert.Equal(t, int64(1000), h.Count())
+	assert.Equal(t, time.Second, h.Max())
+}
+
+func TestMergeAndReset(t *testing.T) {
+	a := New()
+	a.Record(time.Millisecond)
+
+	b := New()
+	b.Record(time.Second)
+
+	a.Merge(b)
+	assert.Equal(t, int64(2), a.Count())
+	assert.Equal(t, time.Second, a.Max())
+	assert.Equal(t, time.Second, a.Quantile(1))
+
+	a.Reset()
+	assert.Zero(t, a.Count())
+	assert.Zero(t, a.Quantile(0.5))
+}
+
+func TestPercentilesEmpty(t *testing.T) {
+	assert.Equal(t, Percentiles{}, New().Percentiles())
+}
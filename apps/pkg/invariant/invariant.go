@@ -0,0 +1,151 @@
+package invariant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codingconcepts/errhandler"
+)
+
+// Result is the outcome of comparing the total balance of every account
+// with the expected total. Balances are kept as strings so that decimal
+// drift isn't lost to floating point.
+type Result struct {
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Drift    string `json:"drift"`
+	Balanced bool   `json:"balanced"`
+	Accounts int    `json:"accounts"`
+
+	// At is the time of the snapshot the balances were read from.
+	At time.Time `json:"at"`
+}
+
+// ErrNoExpectedTotal is returned if the expected total hasn't been
+// recorded, which happens when accounts are seeded by spinctl setup.
+var ErrNoExpectedTotal = errors.New("no expected total (run spinctl setup)")
+
+// Check compares the total balance of every account with the expected
+// total. Both are read from the same follower read snapshot, so the
+// check is consistent without contending with the workload.
+func Check(ctx context.Context, db *sql.DB) (Result, error) {
+	const stmt = `SELECT
+									t.expected::STRING,
+									a.total::STRING,
+									(a.total - t.expected)::STRING,
+									a.total = t.expected,
+									a.accounts,
+									now()
+								FROM account_total AS t, (
+									SELECT COALESCE(sum(balance), 0) AS total, count(*) AS accounts
+									FROM account
+								) AS a
+								AS OF SYSTEM TIME follower_read_timestamp()
+								WHERE t.id = 1`
+
+	var r Result
+	err := db.QueryRowContext(ctx, stmt).Scan(&r.Expected, &r.Actual, &r.Drift, &r.Balanced, &r.Accounts, &r.At)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Result{}, ErrNoExpectedTotal
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return r, nil
+}
+
+// Checker runs Check in the background, keeping the last result.
+type Checker struct {
+	db *sql.DB
+
+	lastMu  sync.RWMutex
+	last    Result
+	lastErr error
+}
+
+// NewChecker returns a Checker for the given database.
+func NewChecker(db *sql.DB) *Checker {
+	return &Checker{
+		db:      db,
+		lastErr: errors.New("not checked yet"),
+	}
+}
+
+// Run checks the invariant every interval until the context is
+// cancelled, logging the outcome of each check.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticks := time.NewTicker(interval)
+	defer ticks.Stop()
+
+	for {
+		c.check(ctx)
+
+		select {
+		case <-ticks.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Checker) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	r, err := Check(ctx, c.db)
+
+	c.lastMu.Lock()
+	c.last, c.lastErr = r, err
+	c.lastMu.Unlock()
+
+	switch {
+	case err != nil:
+		log.Printf("error checking balance invariant: %v", err)
+	case r.Balanced:
+		log.Printf("balance invariant held (accounts: %d, total: %s)", r.Accounts, r.Actual)
+	default:
+		log.Printf("BALANCE DRIFT: %s (accounts: %d, expected: %s, actual: %s)", r.Drift, r.Accounts, r.Expected, r.Actual)
+	}
+}
+
+// Last returns the result of the last check.
+func (c *Checker) Last() (Result, error) {
+	c.lastMu.RLock()
+	defer c.lastMu.RUnlock()
+
+	return c.last, c.lastErr
+}
+
+type getInvariantResponse struct {
+	Result
+	Error string `json:"error,omitempty"`
+}
+
+// Handler returns the result of the last check. It responds with a 409
+// if the balances have drifted and a 503 if the last check failed.
+func (c *Checker) Handler() http.Handler {
+	return errhandler.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		last, err := c.Last()
+
+		resp := getInvariantResponse{Result: last}
+
+		// The status has to be written after the content type.
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case err != nil:
+			resp.Error = err.Error()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case !last.Balanced:
+			w.WriteHeader(http.StatusConflict)
+		}
+
+		return errhandler.SendJSON(w, resp)
+	})
+}
@@ -0,0 +1,83 @@
+package invariant
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		last       Result
+		lastErr    error
+		wantStatus int
+		wantBody   getInvariantResponse
+	}{
+		{
+			name:       "balanced",
+			last:       Result{Expected: "100.00", Actual: "100.00", Drift: "0.00", Balanced: true, Accounts: 2},
+			wantStatus: http.StatusOK,
+			wantBody: getInvariantResponse{
+				Result: Result{Expected: "100.00", Actual: "100.00", Drift: "0.00", Balanced: true, Accounts: 2},
+			},
+		},
+		{
+			name:       "drifted",
+			last:       Result{Expected: "100.00", Actual: "99.50", Drift: "-0.50", Accounts: 2},
+			wantStatus: http.StatusConflict,
+			wantBody: getInvariantResponse{
+				Result: Result{Expected: "100.00", Actual: "99.50", Drift: "-0.50", Accounts: 2},
+			},
+		},
+		{
+			name:       "check failed",
+			lastErr:    ErrNoExpectedTotal,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: getInvariantResponse{
+				Error: "no expected total (run spinctl setup)",
+			},
+		},
+		{
+			name:       "not checked yet",
+			lastErr:    NewChecker(nil).lastErr,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: getInvariantResponse{
+				Error: "not checked yet",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Checker{last: tt.last, lastErr: tt.lastErr}
+
+			rec := httptest.NewRecorder()
+			c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/invariant", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var got getInvariantResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantBody, got)
+		})
+	}
+}
+
+func TestLast(t *testing.T) {
+	c := NewChecker(nil)
+
+	_, err := c.Last()
+	assert.EqualError(t, err, "not checked yet")
+
+	want := errors.New("boom")
+	c.lastErr = want
+
+	_, err = c.Last()
+	assert.Same(t, want, err)
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+type KeyKind string
+
+const (
+	// Every key is equally likely to be picked.
+	KeysUniform KeyKind = "uniform"
+
+	// Keys are picked with a probability inversely proportional to their
+	// rank raised to the power of the skew, so the first few keys get
+	// most of the requests.
+	KeysZipf KeyKind = "zipf"
+
+	// A percentage of requests go to a percentage of the keys, with the
+	// rest spread uniformly over the remaining keys.
+	KeysHotSet KeyKind = "hotset"
+
+	// Keys are picked in order, wrapping around after the last.
+	KeysSequential KeyKind = "sequential"
+)
+
+const (
+	defaultSkew        = 1.1
+	defaultHotRequests = 90
+)
+
+// KeyDistribution is how a region's workers pick the accounts each
+// request is made against, as stored in the workload table.
+type KeyDistribution struct {
+	Kind KeyKind
+
+	// Skew of a zipf distribution.
+	Skew float64
+
+	// Percentage of keys in the hot set, and the percentage of requests
+	// made against them.
+	HotKeys     float64
+	HotRequests float64
+}
+
+// ParseKeyDistribution parses a key distribution in the form "uniform",
+// "sequential", "zipf=<skew>" or "hotset=<keys%>:<requests%>". The skew
+// defaults to 1.1 and the hot set's share of requests to 90%.
+func ParseKeyDistribution(s string) (KeyDistribution, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(s), "=")
+
+	d := KeyDistribution{Kind: KeyKind(strings.TrimSpace(name))}
+	args = strings.TrimSpace(args)
+
+	switch d.Kind {
+	case KeysUniform, KeysSequential:
+		if args != "" {
+			return KeyDistribution{}, fmt.Errorf("%s keys take no arguments", d.Kind)
+		}
+
+	case KeysZipf:
+		d.Skew = defaultSkew
+		if args != "" {
+			skew, err := strconv.ParseFloat(args, 64)
+			if err != nil {
+				return KeyDistribution{}, fmt.Errorf("invalid zipf skew: %w", err)
+			}
+			d.Skew = skew
+		}
+
+		if d.Skew <= 0 {
+			return KeyDistribution{}, fmt.Errorf("zipf skew must be positive")
+		}
+
+	case KeysHotSet:
+		keys, requests, hasRequests := strings.Cut(args, ":")
+
+		hotKeys, err := strconv.ParseFloat(strings.TrimSpace(keys), 64)
+		if err != nil {
+			return KeyDistribution{}, fmt.Errorf("invalid hot set keys: %w", err)
+		}
+		d.HotKeys = hotKeys
+
+		d.HotRequests = defaultHotRequests
+		if hasRequests {
+			hotRequests, err := strconv.ParseFloat(strings.TrimSpace(requests), 64)
+			if err != nil {
+				return KeyDistribution{}, fmt.Errorf("invalid hot set requests: %w", err)
+			}
+			d.HotRequests = hotRequests
+		}
+
+		if d.HotKeys <= 0 || d.HotKeys > 100 {
+			return KeyDistribution{}, fmt.Errorf("hot set keys must be between 0 and 100%%")
+		}
+
+		if d.HotRequests < 0 || d.HotRequests > 100 {
+			return KeyDistribution{}, fmt.Errorf("hot set requests must be between 0 and 100%%")
+		}
+
+	default:
+		return KeyDistribution{}, fmt.Errorf("unsupported key distribution: %q", d.Kind)
+	}
+
+	return d, nil
+}
+
+func (d KeyDistribution) String() string {
+	switch d.Kind {
+	case KeysZipf:
+		return fmt.Sprintf("%s=%s", d.Kind, formatFloat(d.Skew))
+	case KeysHotSet:
+		return fmt.Sprintf("%s=%s:%s", d.Kind, formatFloat(d.HotKeys), formatFloat(d.HotRequests))
+	default:
+		return string(d.Kind)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// KeyPicker picks keys from a distribution. Keys are identified by their
+// index in a list ordered by their position in the key space, so the
+// keys favoured by zipf and hot set distributions form a hot range. It's
+// safe for concurrent use.
+type KeyPicker struct {
+	dist KeyDistribution
+	n    int
+
+	// Number of keys in the hot set.
+	hot int
+
+	// Cumulative probability of picking each key, for zipf.
+	cdf []float64
+
+	// Index of the next key, for sequential.
+	next atomic.Int64
+}
+
+// NewPicker returns a picker for n keys.
+func (d KeyDistribution) NewPicker(n int) *KeyPicker {
+	p := KeyPicker{dist: d, n: n}
+
+	switch d.Kind {
+	case KeysZipf:
+		p.cdf = make([]float64, n)
+
+		var total float64
+		for i := range p.cdf {
+			total += 1 / math.Pow(float64(i+1), d.Skew)
+			p.cdf[i] = total
+		}
+
+		for i := range p.cdf {
+			p.cdf[i] /= total
+		}
+
+	case KeysHotSet:
+		p.hot = min(n, max(1, int(math.Round(float64(n)*d.HotKeys/100))))
+	}
+
+	return &p
+}
+
+// Distribution returns the distribution keys are picked from.
+func (p *KeyPicker) Distribution() KeyDistribution {
+	return p.dist
+}
+
+// Pick returns the index of a key, given u between 0 and 1 (which is
+// ignored by sequential distributions).
+func (p *KeyPicker) Pick(u float64) int {
+	switch p.dist.Kind {
+	case KeysZipf:
+		return min(sort.SearchFloat64s(p.cdf, u), p.n-1)
+
+	case KeysHotSet:
+		share := p.dist.HotRequests / 100
+		if u < share {
+			return min(int(u/share*float64(p.hot)), p.hot-1)
+		}
+		if p.hot == p.n {
+			return min(int(u*float64(p.n)), p.n-1)
+		}
+		return min(p.hot+int((u-share)/(1-share)*float64(p.n-p.hot)), p.n-1)
+
+	case KeysSequential:
+		return int((p.next.Add(1) - 1) % int64(p.n))
+
+	default:
+		return min(int(u*float64(p.n)), p.n-1)
+	}
+}
+
+// PickPair returns the indexes of two different keys, given u and v
+// between 0 and 1. If both pick the same key, the key after it is used
+// for the second.
+func (p *KeyPicker) PickPair(u, v float64) (int, int) {
+	i, j := p.Pick(u), p.Pick(v)
+	if i == j {
+		j = (i + 1) % p.n
+	}
+
+	return i, j
+}
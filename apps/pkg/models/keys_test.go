@@ -0,0 +1,184 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyDistribution(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    KeyDistribution
+		wantErr string
+	}{
+		{
+			name:  "uniform",
+			input: "uniform",
+			want:  KeyDistribution{Kind: KeysUniform},
+		},
+		{
+			name:  "sequential",
+			input: " sequential ",
+			want:  KeyDistribution{Kind: KeysSequential},
+		},
+		{
+			name:  "zipf with default skew",
+			input: "zipf",
+			want:  KeyDistribution{Kind: KeysZipf, Skew: 1.1},
+		},
+		{
+			name:  "zipf with skew",
+			input: "zipf=0.99",
+			want:  KeyDistribution{Kind: KeysZipf, Skew: 0.99},
+		},
+		{
+			name:  "hot set with default requests",
+			input: "hotset=1",
+			want:  KeyDistribution{Kind: KeysHotSet, HotKeys: 1, HotRequests: 90},
+		},
+		{
+			name:  "hot set with requests",
+			input: "hotset=10:50",
+			want:  KeyDistribution{Kind: KeysHotSet, HotKeys: 10, HotRequests: 50},
+		},
+		{
+			name:    "unknown",
+			input:   "gaussian",
+			wantErr: `unsupported key distribution: "gaussian"`,
+		},
+		{
+			name:    "uniform with arguments",
+			input:   "uniform=1",
+			wantErr: "uniform keys take no arguments",
+		},
+		{
+			name:    "zero skew",
+			input:   "zipf=0",
+			wantErr: "zipf skew must be positive",
+		},
+		{
+			name:    "missing hot set keys",
+			input:   "hotset",
+			wantErr: `invalid hot set keys: strconv.ParseFloat: parsing "": invalid syntax`,
+		},
+		{
+			name:    "too many hot set keys",
+			input:   "hotset=101",
+			wantErr: "hot set keys must be between 0 and 100%",
+		},
+		{
+			name:    "too many hot set requests",
+			input:   "hotset=1:101",
+			wantErr: "hot set requests must be between 0 and 100%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyDistribution(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeyDistributionString(t *testing.T) {
+	for _, s := range []string{"uniform", "sequential", "zipf=1.2", "hotset=1:90"} {
+		d, err := ParseKeyDistribution(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, d.String())
+	}
+}
+
+func TestKeyPickerPick(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		u     []float64
+		want  []int
+	}{
+		{
+			name:  "uniform",
+			input: "uniform",
+			u:     []float64{0, 0.5, 0.999},
+			want:  []int{0, 50, 99},
+		},
+		{
+			name:  "hot set",
+			input: "hotset=10:80",
+			u:     []float64{0, 0.4, 0.799, 0.8, 0.999},
+			want:  []int{0, 5, 9, 10, 99},
+		},
+		{
+			name:  "hot set of every key",
+			input: "hotset=100:50",
+			u:     []float64{0.25, 0.75},
+			want:  []int{50, 75},
+		},
+		{
+			name:  "zipf",
+			input: "zipf=1",
+			u:     []float64{0, 0.19, 0.2, 0.997},
+			want:  []int{0, 0, 1, 98},
+		},
+		{
+			name:  "sequential",
+			input: "sequential",
+			u:     []float64{0.9, 0.9, 0.9},
+			want:  []int{0, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseKeyDistribution(tt.input)
+			assert.NoError(t, err)
+
+			p := d.NewPicker(100)
+			for i, u := range tt.u {
+				assert.Equal(t, tt.want[i], p.Pick(u), "u=%v", u)
+			}
+		})
+	}
+}
+
+func TestKeyPickerSequentialWraps(t *testing.T) {
+	p := KeyDistribution{Kind: KeysSequential}.NewPicker(3)
+
+	var got []int
+	for range 5 {
+		got = append(got, p.Pick(0))
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 0, 1}, got)
+}
+
+func TestKeyPickerZipfSkew(t *testing.T) {
+	// The more skewed the distribution, the more requests the first key
+	// gets.
+	low := KeyDistribution{Kind: KeysZipf, Skew: 0.5}.NewPicker(1000)
+	high := KeyDistribution{Kind: KeysZipf, Skew: 2}.NewPicker(1000)
+
+	assert.Greater(t, high.cdf[0], low.cdf[0])
+	assert.InDelta(t, 1, low.cdf[999], 1e-9)
+	assert.InDelta(t, 1, high.cdf[999], 1e-9)
+}
+
+func TestKeyPickerPickPair(t *testing.T) {
+	p := KeyDistribution{Kind: KeysHotSet, HotKeys: 1, HotRequests: 100}.NewPicker(100)
+
+	i, j := p.PickPair(0.1, 0.2)
+	assert.Equal(t, 0, i)
+	assert.Equal(t, 1, j)
+
+	i, j = KeyDistribution{Kind: KeysUniform}.NewPicker(100).PickPair(0.995, 0.999)
+	assert.Equal(t, 99, i)
+	assert.Equal(t, 0, j)
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Operation string
+
+const (
+	// Reads the balance of an account.
+	OperationRead Operation = "read"
+
+	// Moves an amount between two accounts.
+	OperationTransfer Operation = "transfer"
+
+	// Records a transaction against an account.
+	OperationInsert Operation = "insert"
+
+	// Reads a range of accounts.
+	OperationScan Operation = "scan"
+)
+
+// Operations are all of the operations a worker can make.
+var Operations = []Operation{
+	OperationRead,
+	OperationTransfer,
+	OperationInsert,
+	OperationScan,
+}
+
+// Mix is the relative weight of each operation made by a region's
+// workers, as stored in the workload table.
+type Mix map[Operation]int
+
+// ParseMix parses a mix in the form "read=70,transfer=20,insert=5,scan=5".
+// Operations that aren't mentioned are never made.
+func ParseMix(s string) (Mix, error) {
+	m := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected operation=weight", part)
+		}
+
+		op := Operation(strings.TrimSpace(name))
+		if !slices.Contains(Operations, op) {
+			return nil, fmt.Errorf("unsupported operation: %q", op)
+		}
+
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for %s: %w", op, err)
+		}
+
+		if w < 0 {
+			return nil, fmt.Errorf("weight for %s cannot be negative", op)
+		}
+
+		m[op] += w
+	}
+
+	if m.Total() == 0 {
+		return nil, fmt.Errorf("at least one operation must have a positive weight")
+	}
+
+	return m, nil
+}
+
+// Total returns the sum of all weights.
+func (m Mix) Total() int {
+	var total int
+	for _, w := range m {
+		total += w
+	}
+
+	return total
+}
+
+// Pick returns the operation n (between 0 and Total) falls on.
+func (m Mix) Pick(n int) Operation {
+	for _, op := range Operations {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+
+	return OperationTransfer
+}
+
+func (m Mix) String() string {
+	var parts []string
+	for _, op := range Operations {
+		if m[op] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
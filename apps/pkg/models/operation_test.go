@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Mix
+		wantErr string
+	}{
+		{
+			name:  "single operation",
+			input: "transfer=100",
+			want:  Mix{OperationTransfer: 100},
+		},
+		{
+			name:  "all operations",
+			input: "read=70, transfer=20, insert=5, scan=5",
+			want:  Mix{OperationRead: 70, OperationTransfer: 20, OperationInsert: 5, OperationScan: 5},
+		},
+		{
+			name:    "unknown operation",
+			input:   "delete=10",
+			wantErr: `unsupported operation: "delete"`,
+		},
+		{
+			name:    "malformed",
+			input:   "read",
+			wantErr: `invalid mix entry "read", expected operation=weight`,
+		},
+		{
+			name:    "negative weight",
+			input:   "read=-1,transfer=1",
+			wantErr: "weight for read cannot be negative",
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: "at least one operation must have a positive weight",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMix(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMixPick(t *testing.T) {
+	m := Mix{OperationRead: 2, OperationInsert: 1, OperationScan: 1}
+
+	var got []Operation
+	for n := range m.Total() {
+		got = append(got, m.Pick(n))
+	}
+
+	assert.Equal(t, []Operation{OperationRead, OperationRead, OperationInsert, OperationScan}, got)
+	assert.Equal(t, "read=2,insert=1,scan=1", m.String())
+}
@@ -24,13 +24,13 @@ const (
 	// Scales global traffic by 10x for 10 minutes.
 	ScenarioFlashSale Scenario = "flash-sale"
 
-	// High demand for a new product for 10 minutes.
+	// High demand for a new product scales global traffic by 5x for 10 minutes.
 	ScenarioNewProduct Scenario = "new-product"
 
 	// Halves global traffic down for 10 minutes.
 	ScenarioScandal Scenario = "scandal"
 
-	// Tests that messages are reaching service.
+	// Tests that messages are reaching service without changing traffic.
 	ScenarioTest Scenario = "test"
 )
 
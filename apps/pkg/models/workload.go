@@ -0,0 +1,35 @@
+package models
+
+// Workload is a region's load settings, as stored in the workload table.
+type Workload struct {
+	// Number of concurrent workers.
+	Workers int
+
+	// Requests per second made by each worker.
+	Rate int
+
+	// Distribution of the time between each worker's requests.
+	ThinkTime ThinkTime
+
+	// Relative weight of each operation made by workers, in the form
+	// accepted by ParseMix.
+	Mix string
+
+	// Distribution of the accounts requests are made against, in the
+	// form accepted by ParseKeyDistribution.
+	KeyDistribution string
+}
+
+type ThinkTime string
+
+const (
+	// Requests are evenly spaced at 1/rate.
+	ThinkTimeFixed ThinkTime = "fixed"
+
+	// Requests are spaced uniformly between 0 and 2/rate.
+	ThinkTimeUniform ThinkTime = "uniform"
+
+	// Requests are spaced exponentially with a mean of 1/rate, giving
+	// Poisson arrivals.
+	ThinkTimeExponential ThinkTime = "exponential"
+)
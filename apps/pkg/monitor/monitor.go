@@ -0,0 +1,227 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+)
+
+// Region is a snapshot of the Apdex scores fetched from a region's
+// workload runner.
+type Region struct {
+	Name    string
+	Score   float64
+	Windows []Window
+	Err     error
+	At      time.Time
+	History []float64
+}
+
+// Window is how the requests made within a window (e.g. "10s") were
+// scored by a region.
+type Window struct {
+	Window string `json:"window"`
+	apdex.Summary
+}
+
+// Status is the response from a region's /apdex endpoint.
+type Status struct {
+	Score   float64  `json:"score"`
+	Windows []Window `json:"windows"`
+}
+
+// Window returns how the requests made within a window were scored.
+func (s Status) Window(window string) (apdex.Summary, bool) {
+	i := slices.IndexFunc(s.Windows, func(w Window) bool { return w.Window == window })
+	if i == -1 {
+		return apdex.Summary{}, false
+	}
+
+	return s.Windows[i].Summary, true
+}
+
+// Services maps each region to its workload service URL, ignoring any
+// that weren't provided.
+func Services(apURL, euURL, usURL string) map[string]string {
+	services := map[string]string{}
+	for region, url := range map[string]string{
+		models.RegionAP: apURL,
+		models.RegionEU: euURL,
+		models.RegionUS: usURL,
+	} {
+		if url != "" {
+			services[region] = url
+		}
+	}
+
+	return services
+}
+
+// Monitor polls the /apdex endpoint of each region's workload runner in
+// the background, keeping a short history of scores for each.
+type Monitor struct {
+	client      *http.Client
+	services    map[string]string
+	historySize int
+
+	regionsMu sync.RWMutex
+	regions   map[string]*Region
+}
+
+// New returns a Monitor for the given region to service URL mapping,
+// keeping up to historySize scores per region.
+func New(services map[string]string, historySize int) *Monitor {
+	regions := map[string]*Region{}
+	for name := range services {
+		regions[name] = &Region{Name: name}
+	}
+
+	return &Monitor{
+		client:      &http.Client{Timeout: time.Second * 5},
+		services:    services,
+		historySize: historySize,
+		regions:     regions,
+	}
+}
+
+// Run polls every region each interval until the context is cancelled.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticks := time.NewTicker(interval)
+	defer ticks.Stop()
+
+	for {
+		m.poll(ctx)
+
+		select {
+		case <-ticks.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Regions returns a snapshot of each region, ordered by name.
+func (m *Monitor) Regions() []Region {
+	m.regionsMu.RLock()
+	defer m.regionsMu.RUnlock()
+
+	var out []Region
+	for _, name := range slices.Sorted(maps.Keys(m.regions)) {
+		r := *m.regions[name]
+		r.History = slices.Clone(r.History)
+		r.Windows = slices.Clone(r.Windows)
+		out = append(out, r)
+	}
+
+	return out
+}
+
+// Global returns the request-weighted Apdex summary of the given window
+// across every region that last responded successfully.
+func (m *Monitor) Global(window string) apdex.Summary {
+	m.regionsMu.RLock()
+	defer m.regionsMu.RUnlock()
+
+	var summaries []apdex.Summary
+	for _, r := range m.regions {
+		if r.Err != nil {
+			continue
+		}
+
+		if s, ok := (Status{Windows: r.Windows}).Window(window); ok {
+			summaries = append(summaries, s)
+		}
+	}
+
+	return apdex.Aggregate(summaries...)
+}
+
+func (m *Monitor) poll(ctx context.Context) {
+	for _, f := range FetchAll(ctx, m.client, m.services) {
+		if f.Err != nil {
+			log.Printf("error fetching apdex for %s: %v", f.Region, f.Err)
+		}
+
+		m.record(f.Region, f.Status, f.Err)
+	}
+}
+
+// Fetched is the outcome of fetching a region's Apdex status.
+type Fetched struct {
+	Region string
+	Status Status
+	Err    error
+}
+
+// FetchAll fetches the Apdex status of every region concurrently,
+// ordered by region.
+func FetchAll(ctx context.Context, client *http.Client, services map[string]string) []Fetched {
+	var wg sync.WaitGroup
+	out := make([]Fetched, len(services))
+	for i, region := range slices.Sorted(maps.Keys(services)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status, err := Fetch(ctx, client, services[region])
+			out[i] = Fetched{Region: region, Status: status, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return out
+}
+
+// Fetch returns the Apdex status of a region's workload runner.
+func Fetch(ctx context.Context, client *http.Client, url string) (Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(url, "/")+"/apdex", nil)
+	if err != nil {
+		return Status{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Status{}, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var status Status
+	if err = json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Status{}, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return status, nil
+}
+
+func (m *Monitor) record(name string, status Status, err error) {
+	m.regionsMu.Lock()
+	defer m.regionsMu.Unlock()
+
+	r := m.regions[name]
+	r.At = time.Now()
+	r.Err = err
+	if err != nil {
+		return
+	}
+
+	r.Score = status.Score
+	r.Windows = status.Windows
+	r.History = append(r.History, status.Score)
+	if len(r.History) > m.historySize {
+		r.History = r.History[len(r.History)-m.historySize:]
+	}
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServices(t *testing.T) {
+	got := Services("http://ap", "", "http://us")
+	assert.Equal(t, map[string]string{
+		models.RegionAP: "http://ap",
+		models.RegionUS: "http://us",
+	}, got)
+
+	assert.Empty(t, Services("", "", ""))
+}
+
+func TestStatusWindow(t *testing.T) {
+	s := Status{Windows: []Window{
+		{Window: "10s", Summary: apdex.Summary{Satisfied: 1, Total: 1}},
+		{Window: "1m", Summary: apdex.Summary{Satisfied: 2, Total: 2}},
+	}}
+
+	got, ok := s.Window("1m")
+	assert.True(t, ok)
+	assert.Equal(t, apdex.Summary{Satisfied: 2, Total: 2}, got)
+
+	_, ok = s.Window("5m")
+	assert.False(t, ok)
+}
+
+func TestFetchAll(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apdex", r.URL.Path)
+		w.Write([]byte(`{"score": 0.5, "windows": [{"window": "10s", "satisfied": 1, "frustrated": 1, "total": 1}]}`))
+	}))
+	defer up.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	got := FetchAll(context.Background(), up.Client(), map[string]string{
+		models.RegionUS: down.URL,
+		models.RegionAP: up.URL + "/",
+	})
+
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, models.RegionAP, got[0].Region)
+	assert.NoError(t, got[0].Err)
+	assert.Equal(t, Status{
+		Score:   0.5,
+		Windows: []Window{{Window: "10s", Summary: apdex.Summary{Satisfied: 1, Frustrated: 1, Total: 1}}},
+	}, got[0].Status)
+
+	assert.Equal(t, models.RegionUS, got[1].Region)
+	assert.EqualError(t, got[1].Err, "unexpected status: 503 Service Unavailable")
+}
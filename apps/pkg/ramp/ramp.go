@@ -0,0 +1,110 @@
+package ramp
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type Shape string
+
+const (
+	// Jumps straight to the target.
+	ShapeInstant Shape = "instant"
+
+	// Moves linearly towards the target over Ramp.
+	ShapeLinear Shape = "linear"
+
+	// Climbs towards the target in Steps equal rungs over Ramp.
+	ShapeStep Shape = "step"
+
+	// Oscillates between the previous value and the target every Period.
+	ShapeSine Shape = "sine"
+
+	// Jumps straight to the target and decays back towards the
+	// previous value, halving the difference every HalfLife.
+	ShapeSpike Shape = "spike"
+)
+
+// Profile describes how a scenario moves a region's workers from their
+// previous value to the scenario's target over time.
+type Profile struct {
+	Shape    Shape         `yaml:"shape" json:"shape"`
+	Ramp     time.Duration `yaml:"ramp" json:"ramp"`
+	Steps    int           `yaml:"steps" json:"steps"`
+	Period   time.Duration `yaml:"period" json:"period"`
+	HalfLife time.Duration `yaml:"half_life" json:"half_life"`
+}
+
+func (p Profile) Validate() error {
+	switch p.Shape {
+	case "", ShapeInstant:
+	case ShapeLinear:
+		if p.Ramp <= 0 {
+			return fmt.Errorf("ramp must be positive for %s", p.Shape)
+		}
+	case ShapeStep:
+		if p.Ramp <= 0 {
+			return fmt.Errorf("ramp must be positive for %s", p.Shape)
+		}
+		if p.Steps <= 0 {
+			return fmt.Errorf("steps must be positive for %s", p.Shape)
+		}
+	case ShapeSine:
+		if p.Period <= 0 {
+			return fmt.Errorf("period must be positive for %s", p.Shape)
+		}
+	case ShapeSpike:
+		if p.HalfLife <= 0 {
+			return fmt.Errorf("half_life must be positive for %s", p.Shape)
+		}
+	default:
+		return fmt.Errorf("unsupported shape: %q", p.Shape)
+	}
+
+	return nil
+}
+
+// Level returns how far between the previous value (0) and the target
+// (1) a region should be, elapsed into the scenario.
+func (p Profile) Level(elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	switch p.Shape {
+	case ShapeLinear:
+		return min(float64(elapsed)/float64(p.Ramp), 1)
+
+	case ShapeStep:
+		progress := min(float64(elapsed)/float64(p.Ramp), 1)
+		return math.Floor(progress*float64(p.Steps)) / float64(p.Steps)
+
+	case ShapeSine:
+		return (1 - math.Cos(2*math.Pi*float64(elapsed)/float64(p.Period))) / 2
+
+	case ShapeSpike:
+		return math.Pow(0.5, float64(elapsed)/float64(p.HalfLife))
+
+	default:
+		return 1
+	}
+}
+
+// Settled returns true once the profile's level will no longer change.
+// Sine and spike profiles never settle.
+func (p Profile) Settled(elapsed time.Duration) bool {
+	switch p.Shape {
+	case ShapeLinear, ShapeStep:
+		return elapsed >= p.Ramp
+	case ShapeSine, ShapeSpike:
+		return false
+	default:
+		return true
+	}
+}
+
+// Between returns the value at a given level between from and to.
+func Between(from, to int, level float64) int {
+	return int(math.Round(float64(from) + float64(to-from)*level))
+}
@@ -0,0 +1,91 @@
+package ramp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		elapsed time.Duration
+		want    float64
+	}{
+		{
+			name:    "instant",
+			profile: Profile{},
+			elapsed: 0,
+			want:    1,
+		},
+		{
+			name:    "linear start",
+			profile: Profile{Shape: ShapeLinear, Ramp: time.Minute},
+			elapsed: 0,
+			want:    0,
+		},
+		{
+			name:    "linear midway",
+			profile: Profile{Shape: ShapeLinear, Ramp: time.Minute},
+			elapsed: time.Second * 30,
+			want:    0.5,
+		},
+		{
+			name:    "linear after ramp",
+			profile: Profile{Shape: ShapeLinear, Ramp: time.Minute},
+			elapsed: time.Minute * 2,
+			want:    1,
+		},
+		{
+			name:    "step between rungs",
+			profile: Profile{Shape: ShapeStep, Ramp: time.Minute, Steps: 4},
+			elapsed: time.Second * 40,
+			want:    0.5,
+		},
+		{
+			name:    "step after ramp",
+			profile: Profile{Shape: ShapeStep, Ramp: time.Minute, Steps: 4},
+			elapsed: time.Minute,
+			want:    1,
+		},
+		{
+			name:    "sine start",
+			profile: Profile{Shape: ShapeSine, Period: time.Minute},
+			elapsed: 0,
+			want:    0,
+		},
+		{
+			name:    "sine peak",
+			profile: Profile{Shape: ShapeSine, Period: time.Minute},
+			elapsed: time.Second * 30,
+			want:    1,
+		},
+		{
+			name:    "spike start",
+			profile: Profile{Shape: ShapeSpike, HalfLife: time.Minute},
+			elapsed: 0,
+			want:    1,
+		},
+		{
+			name:    "spike after two half lives",
+			profile: Profile{Shape: ShapeSpike, HalfLife: time.Minute},
+			elapsed: time.Minute * 2,
+			want:    0.25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.profile.Level(tt.elapsed), 1e-9)
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	assert.Equal(t, 10, Between(10, 20, 0))
+	assert.Equal(t, 15, Between(10, 20, 0.5))
+	assert.Equal(t, 20, Between(10, 20, 1))
+	assert.Equal(t, 8, Between(10, 5, 0.4))
+}
@@ -3,45 +3,60 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
 )
 
+// ErrAccountNotFound is returned when a transfer's account doesn't exist
+// (or, for the multi-region repo, isn't in the repo's region), so that
+// the transfer is rolled back rather than creating or destroying money.
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrUnexpectedRows is returned when a transfer's update matches more
+// than one account, so that the transfer is rolled back.
+var ErrUnexpectedRows = errors.New("unexpected rows")
+
 type PostgresRepo struct {
-	db *sql.DB
+	db    *sql.DB
+	retry RetryPolicy
 }
 
-func NewPostgresRepo(db *sql.DB) *PostgresRepo {
+func NewPostgresRepo(db *sql.DB, retry RetryPolicy) *PostgresRepo {
 	return &PostgresRepo{
-		db: db,
+		db:    db,
+		retry: retry,
 	}
 }
 
-func (r *PostgresRepo) FetchWorkers(ctx context.Context, region string) (int, error) {
-	const stmt = `SELECT workers
+func (r *PostgresRepo) FetchWorkload(ctx context.Context, region string) (models.Workload, error) {
+	const stmt = `SELECT workers, rate, think_time, mix, key_distribution
 								FROM workload
 								WHERE region = $1
 								LIMIT 1`
 
 	row := r.db.QueryRowContext(ctx, stmt, region)
 
-	var workers int
-	if err := row.Scan(&workers); err != nil {
-		return 0, fmt.Errorf("scanning row: %w", err)
+	var w models.Workload
+	if err := row.Scan(&w.Workers, &w.Rate, &w.ThinkTime, &w.Mix, &w.KeyDistribution); err != nil {
+		return models.Workload{}, fmt.Errorf("scanning row: %w", err)
 	}
 
-	return workers, nil
+	return w, nil
 }
 
 func (r *PostgresRepo) FetchIDs(ctx context.Context) ([]any, error) {
 	const stmt = `SELECT id
 								FROM account
-								ORDER BY random()
-								LIMIT $2`
+								ORDER BY id
+								LIMIT $1`
 
 	rows, err := r.db.QueryContext(ctx, stmt, 1000)
 	if err != nil {
 		return nil, fmt.Errorf("making query: %w", err)
 	}
+	defer rows.Close()
 
 	var ids []any
 	var id string
@@ -53,20 +68,118 @@ func (r *PostgresRepo) FetchIDs(ctx context.Context) ([]any, error) {
 		ids = append(ids, id)
 	}
 
-	return ids, nil
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepo) ReadBalance(ctx context.Context, id any) (float64, error) {
+	const stmt = `SELECT balance
+								FROM account
+								WHERE id = $1`
+
+	row := r.db.QueryRowContext(ctx, stmt, id)
+
+	var balance float64
+	if err := row.Scan(&balance); err != nil {
+		return 0, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return balance, nil
 }
 
-func (r *PostgresRepo) MakeRequest(ctx context.Context, idFrom, idTo any, amount float64) error {
-	const stmt = `UPDATE account
-									SET balance = CASE 
-										WHEN id = $1 THEN balance - $3
-										WHEN id = $2 THEN balance + $3
-									END
-								WHERE id IN ($1, $2)`
+// Transfer moves amount between two accounts and records both sides of
+// the transfer in the transaction history, in a single transaction. It
+// returns the number of times the transaction was retried.
+func (r *PostgresRepo) Transfer(ctx context.Context, idFrom, idTo any, amount float64) (int, error) {
+	const debitStmt = `UPDATE account
+											SET balance = balance - $2
+										WHERE id = $1`
 
-	if _, err := r.db.ExecContext(ctx, stmt, idFrom, idTo, amount); err != nil {
+	const creditStmt = `UPDATE account
+											SET balance = balance + $2
+										WHERE id = $1`
+
+	return inTx(ctx, r.db, r.retry, func(tx *sql.Tx) error {
+		if err := updateOne(tx.ExecContext(ctx, debitStmt, idFrom, amount)); err != nil {
+			return fmt.Errorf("debiting account: %w", err)
+		}
+
+		if err := updateOne(tx.ExecContext(ctx, creditStmt, idTo, amount)); err != nil {
+			return fmt.Errorf("crediting account: %w", err)
+		}
+
+		return insertLedger(ctx, tx, idFrom, idTo, amount)
+	})
+}
+
+func (r *PostgresRepo) InsertTransaction(ctx context.Context, id any, amount float64) error {
+	const stmt = `INSERT INTO transaction_history (account_id, amount)
+								VALUES ($1, $2)`
+
+	if _, err := r.db.ExecContext(ctx, stmt, id, amount); err != nil {
 		return fmt.Errorf("making request: %w", err)
 	}
 
 	return nil
 }
+
+func (r *PostgresRepo) ScanAccounts(ctx context.Context, idFrom any, limit int) (int, error) {
+	const stmt = `SELECT id, balance
+								FROM account
+								WHERE id >= $1
+								ORDER BY id
+								LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, stmt, idFrom, limit)
+	if err != nil {
+		return 0, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	var id string
+	var balance float64
+
+	for rows.Next() {
+		if err = rows.Scan(&id, &balance); err != nil {
+			return 0, fmt.Errorf("scanning row: %w", err)
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// updateOne checks that an update to an account updated exactly one
+// row.
+func updateOne(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("counting rows: %w", err)
+	}
+
+	switch rows {
+	case 1:
+		return nil
+	case 0:
+		return ErrAccountNotFound
+	default:
+		return fmt.Errorf("updated %d rows: %w", rows, ErrUnexpectedRows)
+	}
+}
+
+// insertLedger records both sides of a transfer in the transaction
+// history.
+func insertLedger(ctx context.Context, tx *sql.Tx, idFrom, idTo any, amount float64) error {
+	const stmt = `INSERT INTO transaction_history (account_id, amount)
+								VALUES ($1, -$3::DECIMAL), ($2, $3::DECIMAL)`
+
+	if _, err := tx.ExecContext(ctx, stmt, idFrom, idTo, amount); err != nil {
+		return fmt.Errorf("inserting ledger rows: %w", err)
+	}
+
+	return nil
+}
@@ -4,47 +4,52 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
 )
 
 type PostgresRepoMR struct {
 	db     *sql.DB
 	region string
+	retry  RetryPolicy
 }
 
-func NewPostgresRepoMR(db *sql.DB, region string) *PostgresRepoMR {
+func NewPostgresRepoMR(db *sql.DB, region string, retry RetryPolicy) *PostgresRepoMR {
 	return &PostgresRepoMR{
 		db:     db,
 		region: region,
+		retry:  retry,
 	}
 }
 
-func (r *PostgresRepoMR) FetchWorkers(ctx context.Context, region string) (int, error) {
-	const stmt = `SELECT workers
+func (r *PostgresRepoMR) FetchWorkload(ctx context.Context, region string) (models.Workload, error) {
+	const stmt = `SELECT workers, rate, think_time, mix, key_distribution
 								FROM workload
 								WHERE region = $1
 								LIMIT 1`
 
 	row := r.db.QueryRowContext(ctx, stmt, region)
 
-	var workers int
-	if err := row.Scan(&workers); err != nil {
-		return 0, fmt.Errorf("scanning row: %w", err)
+	var w models.Workload
+	if err := row.Scan(&w.Workers, &w.Rate, &w.ThinkTime, &w.Mix, &w.KeyDistribution); err != nil {
+		return models.Workload{}, fmt.Errorf("scanning row: %w", err)
 	}
 
-	return workers, nil
+	return w, nil
 }
 
 func (r *PostgresRepoMR) FetchIDs(ctx context.Context) ([]any, error) {
 	const stmt = `SELECT id
 								FROM account
 								WHERE crdb_region = $1
-								ORDER BY random()
+								ORDER BY id
 								LIMIT $2`
 
 	rows, err := r.db.QueryContext(ctx, stmt, r.region, 1000)
 	if err != nil {
 		return nil, fmt.Errorf("making query: %w", err)
 	}
+	defer rows.Close()
 
 	var ids []any
 	var id string
@@ -56,21 +61,88 @@ func (r *PostgresRepoMR) FetchIDs(ctx context.Context) ([]any, error) {
 		ids = append(ids, id)
 	}
 
-	return ids, nil
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepoMR) ReadBalance(ctx context.Context, id any) (float64, error) {
+	const stmt = `SELECT balance
+								FROM account
+								WHERE id = $1
+								AND crdb_region = $2`
+
+	row := r.db.QueryRowContext(ctx, stmt, id, r.region)
+
+	var balance float64
+	if err := row.Scan(&balance); err != nil {
+		return 0, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return balance, nil
+}
+
+// Transfer moves amount between two accounts in the repo's region and
+// records both sides of the transfer in the transaction history, in a
+// single transaction. It returns the number of times the transaction was
+// retried.
+func (r *PostgresRepoMR) Transfer(ctx context.Context, idFrom, idTo any, amount float64) (int, error) {
+	const debitStmt = `UPDATE account
+											SET balance = balance - $2
+										WHERE id = $1
+										AND crdb_region = $3`
+
+	const creditStmt = `UPDATE account
+											SET balance = balance + $2
+										WHERE id = $1
+										AND crdb_region = $3`
+
+	return inTx(ctx, r.db, r.retry, func(tx *sql.Tx) error {
+		if err := updateOne(tx.ExecContext(ctx, debitStmt, idFrom, amount, r.region)); err != nil {
+			return fmt.Errorf("debiting account: %w", err)
+		}
+
+		if err := updateOne(tx.ExecContext(ctx, creditStmt, idTo, amount, r.region)); err != nil {
+			return fmt.Errorf("crediting account: %w", err)
+		}
+
+		return insertLedger(ctx, tx, idFrom, idTo, amount)
+	})
 }
 
-func (r *PostgresRepoMR) MakeRequest(ctx context.Context, idFrom, idTo any, amount float64) error {
-	const stmt = `UPDATE account
-									SET balance = CASE 
-										WHEN id = $1 THEN balance - $3
-										WHEN id = $2 THEN balance + $3
-									END
-								WHERE id IN ($1, $2)
-								AND crdb_region = $4`
+func (r *PostgresRepoMR) InsertTransaction(ctx context.Context, id any, amount float64) error {
+	const stmt = `INSERT INTO transaction_history (account_id, amount)
+								VALUES ($1, $2)`
 
-	if _, err := r.db.ExecContext(ctx, stmt, idFrom, idTo, amount, r.region); err != nil {
+	if _, err := r.db.ExecContext(ctx, stmt, id, amount); err != nil {
 		return fmt.Errorf("making request: %w", err)
 	}
 
 	return nil
 }
+
+func (r *PostgresRepoMR) ScanAccounts(ctx context.Context, idFrom any, limit int) (int, error) {
+	const stmt = `SELECT id, balance
+								FROM account
+								WHERE id >= $1
+								AND crdb_region = $3
+								ORDER BY id
+								LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, stmt, idFrom, limit, r.region)
+	if err != nil {
+		return 0, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	var id string
+	var balance float64
+
+	for rows.Next() {
+		if err = rows.Scan(&id, &balance); err != nil {
+			return 0, fmt.Errorf("scanning row: %w", err)
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
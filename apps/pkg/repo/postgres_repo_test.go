@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+type result struct {
+	rows int64
+	err  error
+}
+
+func (r result) LastInsertId() (int64, error) { return 0, nil }
+func (r result) RowsAffected() (int64, error) { return r.rows, r.err }
+
+func TestUpdateOne(t *testing.T) {
+	errSerialization := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name      string
+		res       result
+		err       error
+		wantErr   string
+		wantIs    error
+		retryable bool
+	}{
+		{
+			name: "one row",
+			res:  result{rows: 1},
+		},
+		{
+			name:    "missing account",
+			res:     result{rows: 0},
+			wantErr: "account not found",
+			wantIs:  ErrAccountNotFound,
+		},
+		{
+			name:    "too many rows",
+			res:     result{rows: 2},
+			wantErr: "updated 2 rows: unexpected rows",
+			wantIs:  ErrUnexpectedRows,
+		},
+		{
+			name:    "rows unavailable",
+			res:     result{err: errors.New("boom")},
+			wantErr: "counting rows: boom",
+		},
+		{
+			name:      "request failed",
+			err:       errSerialization,
+			wantErr:   "making request: :  (SQLSTATE 40001)",
+			wantIs:    errSerialization,
+			retryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := updateOne(tt.res, tt.err)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.wantErr)
+			if tt.wantIs != nil {
+				assert.ErrorIs(t, err, tt.wantIs)
+			}
+			assert.Equal(t, tt.retryable, retryable(err))
+		})
+	}
+}
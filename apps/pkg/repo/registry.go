@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// Accounts are read and written wherever they are.
+	ModeSingleRegion = "single-region"
+
+	// Accounts are only read and written in the workload's own region,
+	// for databases with a REGIONAL BY ROW account table.
+	ModeMultiRegion = "multi-region"
+)
+
+// Config is everything a repo may need to be created.
+type Config struct {
+	DB     *sql.DB
+	Region string
+	Retry  RetryPolicy
+}
+
+// Factory creates a repo from its configuration.
+type Factory func(c Config) (Repo, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		ModeSingleRegion: func(c Config) (Repo, error) {
+			return NewPostgresRepo(c.DB, c.Retry), nil
+		},
+		ModeMultiRegion: func(c Config) (Repo, error) {
+			if c.Region == "" {
+				return nil, fmt.Errorf("missing region")
+			}
+			return NewPostgresRepoMR(c.DB, c.Region, c.Retry), nil
+		},
+	}
+)
+
+// Register makes a repo implementation available under the given mode,
+// replacing any already registered under it.
+func Register(mode string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[mode] = f
+}
+
+// Modes returns the registered modes, in alphabetical order.
+func Modes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// New creates the repo registered under the given mode.
+func New(mode string, c Config) (Repo, error) {
+	registryMu.RLock()
+	f, ok := registry[mode]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported repo mode: %q (expected one of %s)", mode, strings.Join(Modes(), ", "))
+	}
+
+	r, err := f(c)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s repo: %w", mode, err)
+	}
+
+	return r, nil
+}
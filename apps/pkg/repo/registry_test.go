@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		config   Config
+		wantType Repo
+		wantErr  string
+	}{
+		{
+			name:     "single-region",
+			mode:     ModeSingleRegion,
+			wantType: &PostgresRepo{},
+		},
+		{
+			name:     "multi-region",
+			mode:     ModeMultiRegion,
+			config:   Config{Region: "gcp-europe-west2"},
+			wantType: &PostgresRepoMR{},
+		},
+		{
+			name:    "multi-region without region",
+			mode:    ModeMultiRegion,
+			wantErr: "creating multi-region repo: missing region",
+		},
+		{
+			name:    "unknown",
+			mode:    "sharded",
+			wantErr: `unsupported repo mode: "sharded" (expected one of multi-region, single-region)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.mode, tt.config)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.IsType(t, tt.wantType, got)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	want := &PostgresRepo{}
+	Register("test", func(c Config) (Repo, error) { return want, nil })
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "test")
+		registryMu.Unlock()
+	})
+
+	assert.Contains(t, Modes(), "test")
+
+	got, err := New("test", Config{})
+	assert.NoError(t, err)
+	assert.Same(t, want, got)
+}
@@ -1,9 +1,19 @@
 package repo
 
-import "context"
+import (
+	"context"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+)
 
 type Repo interface {
-	FetchWorkers(ctx context.Context, region string) (int, error)
+	FetchWorkload(ctx context.Context, region string) (models.Workload, error)
+
+	// FetchIDs returns the first 1,000 account ids, in key order.
 	FetchIDs(ctx context.Context) ([]any, error)
-	MakeRequest(ctx context.Context, idFrom, idTo any, amount float64) error
+
+	ReadBalance(ctx context.Context, id any) (float64, error)
+	Transfer(ctx context.Context, idFrom, idTo any, amount float64) (int, error)
+	InsertTransaction(ctx context.Context, id any, amount float64) error
+	ScanAccounts(ctx context.Context, idFrom any, limit int) (int, error)
 }
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy controls how transactions aborted due to contention are
+// retried.
+type RetryPolicy struct {
+	// Maximum number of retries after the first attempt.
+	MaxRetries int
+
+	// Delay before the first retry, doubling with each retry up to
+	// MaxDelay. A random delay of up to this value is used, so that
+	// contending transactions don't retry in lockstep.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy retries up to 5 times, waiting up to 10ms before the
+// first retry and up to 200ms before the last.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  time.Millisecond * 10,
+	MaxDelay:   time.Millisecond * 200,
+}
+
+// Retry runs fn, retrying it while it fails with a serialization error
+// (SQLSTATE 40001), and returns the number of retries made.
+func (p RetryPolicy) Retry(ctx context.Context, fn func() error) (int, error) {
+	for retries := 0; ; retries++ {
+		err := fn()
+		if err == nil || !retryable(err) {
+			return retries, err
+		}
+
+		if retries >= p.MaxRetries {
+			return retries, fmt.Errorf("giving up after %d retries: %w", retries, err)
+		}
+
+		select {
+		case <-time.After(p.delay(retries)):
+		case <-ctx.Done():
+			return retries, err
+		}
+	}
+}
+
+// delay returns a random delay before the given retry.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := min(p.BaseDelay<<retry, p.MaxDelay)
+	if d <= 0 {
+		return 0
+	}
+
+	return rand.N(d) + 1
+}
+
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// inTx runs fn in a transaction, retrying the whole transaction if it's
+// aborted due to contention, and returns the number of retries made.
+func inTx(ctx context.Context, db *sql.DB, policy RetryPolicy, fn func(tx *sql.Tx) error) (int, error) {
+	return policy.Retry(ctx, func() error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("beginning transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err = fn(tx); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("committing transaction: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	errSerialization := fmt.Errorf("making request: %w", &pgconn.PgError{Code: "40001"})
+	errOther := errors.New("boom")
+
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Microsecond, MaxDelay: time.Microsecond}
+
+	tests := []struct {
+		name        string
+		errs        []error
+		wantRetries int
+		wantErr     error
+	}{
+		{
+			name:        "succeeds first time",
+			errs:        []error{nil},
+			wantRetries: 0,
+		},
+		{
+			name:        "succeeds after serialization errors",
+			errs:        []error{errSerialization, errSerialization, nil},
+			wantRetries: 2,
+		},
+		{
+			name:        "doesn't retry other errors",
+			errs:        []error{errOther},
+			wantRetries: 0,
+			wantErr:     errOther,
+		},
+		{
+			name:        "gives up after max retries",
+			errs:        []error{errSerialization, errSerialization, errSerialization, errSerialization, nil},
+			wantRetries: 3,
+			wantErr:     errSerialization,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			retries, err := policy.Retry(context.Background(), func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			assert.Equal(t, tt.wantRetries, retries)
+			assert.Equal(t, tt.wantRetries+1, attempts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for retry, limit := range []time.Duration{10, 20, 40, 50, 50} {
+		for range 100 {
+			d := policy.delay(retry)
+			assert.Greater(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, limit*time.Millisecond)
+		}
+	}
+}
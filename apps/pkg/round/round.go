@@ -0,0 +1,97 @@
+package round
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+)
+
+// Round is the window after a scenario lands, during which the database
+// must scale to keep the global Apdex score above a target.
+type Round struct {
+	Scenario  models.Scenario
+	StartedAt time.Time
+	Duration  time.Duration
+	Target    float64
+
+	samples int
+	summary apdex.Summary
+}
+
+// Verdict is the outcome of a finished round.
+type Verdict struct {
+	Scenario  models.Scenario
+	StartedAt time.Time
+	EndedAt   time.Time
+	Target    float64
+	Score     float64
+	Samples   int
+	Passed    bool
+}
+
+func New(s models.Scenario, duration time.Duration, target float64) *Round {
+	return &Round{
+		Scenario:  s,
+		StartedAt: time.Now(),
+		Duration:  duration,
+		Target:    target,
+	}
+}
+
+// Sample records the global Apdex summary of all regions at a point in
+// time. Samples without any requests are ignored.
+func (r *Round) Sample(s apdex.Summary) {
+	if s.Samples() == 0 {
+		return
+	}
+
+	r.summary.Merge(s)
+	r.samples++
+}
+
+// Score returns the score of every request sampled so far, so that
+// samples (and regions) covering more requests count for more.
+func (r *Round) Score() float64 {
+	return r.summary.Score()
+}
+
+// Remaining returns the time left in the round.
+func (r *Round) Remaining() time.Duration {
+	return max(r.Duration-time.Since(r.StartedAt), 0)
+}
+
+func (r *Round) Done() bool {
+	return r.Remaining() == 0
+}
+
+// Verdict judges the round against its target. A round without any
+// samples fails, as there's no evidence the database kept up.
+func (r *Round) Verdict() Verdict {
+	score := r.Score()
+
+	return Verdict{
+		Scenario:  r.Scenario,
+		StartedAt: r.StartedAt,
+		EndedAt:   r.StartedAt.Add(r.Duration),
+		Target:    r.Target,
+		Score:     score,
+		Samples:   r.samples,
+		Passed:    r.samples > 0 && score >= r.Target,
+	}
+}
+
+// Save stores a verdict in the game_round table.
+func Save(ctx context.Context, db *sql.DB, v Verdict) error {
+	const stmt = `INSERT INTO game_round (scenario, started_at, ended_at, target, score, samples, passed)
+								VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := db.ExecContext(ctx, stmt, string(v.Scenario), v.StartedAt, v.EndedAt, v.Target, v.Score, v.Samples, v.Passed); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package round
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerdict(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []apdex.Summary
+		target     float64
+		wantScore  float64
+		wantPassed bool
+	}{
+		{
+			name:       "no samples",
+			target:     0.85,
+			wantScore:  0,
+			wantPassed: false,
+		},
+		{
+			name:       "above target",
+			samples:    []apdex.Summary{{Satisfied: 9, Frustrated: 1, Total: 9}, {Satisfied: 9, Frustrated: 1, Total: 9}},
+			target:     0.85,
+			wantScore:  0.9,
+			wantPassed: true,
+		},
+		{
+			name:       "below target",
+			samples:    []apdex.Summary{{Satisfied: 5, Frustrated: 5, Total: 5}, {Satisfied: 9, Frustrated: 1, Total: 9}},
+			target:     0.85,
+			wantScore:  0.7,
+			wantPassed: false,
+		},
+		{
+			name: "weighted by requests",
+			// A quiet, slow sample doesn't drag down a busy, fast one as
+			// much as an unweighted mean of their scores (0.55) would.
+			samples:    []apdex.Summary{{Satisfied: 90, Frustrated: 10, Total: 90}, {Satisfied: 2, Frustrated: 8, Total: 2}},
+			target:     0.85,
+			wantScore:  92.0 / 110,
+			wantPassed: false,
+		},
+		{
+			name:       "empty samples ignored",
+			samples:    []apdex.Summary{{}, {Satisfied: 9, Frustrated: 1, Total: 9}},
+			target:     0.85,
+			wantScore:  0.9,
+			wantPassed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("test", time.Minute, tt.target)
+			for _, s := range tt.samples {
+				r.Sample(s)
+			}
+
+			v := r.Verdict()
+			assert.InDelta(t, tt.wantScore, v.Score, 1e-9)
+			assert.Equal(t, tt.wantPassed, v.Passed)
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	r := New("test", time.Minute, 0.85)
+	assert.False(t, r.Done())
+	assert.InDelta(t, time.Minute, r.Remaining(), float64(time.Second))
+
+	r.StartedAt = time.Now().Add(-time.Minute * 2)
+	assert.True(t, r.Done())
+	assert.Zero(t, r.Remaining())
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+)
+
+// latencyBuckets are the upper bounds (in seconds) of the request
+// latency histogram exposed on /metrics.
+var latencyBuckets = []float64{0.005, 0.01, 0.02, 0.05, 0.075, 0.1, 0.25, 0.5, 1}
+
+type operationMetrics struct {
+	requests int64
+	retries  int64
+	errors   map[ErrorClass]int64
+	apdex    float64
+
+	// Counts of successful requests per latency bucket, with a final
+	// bucket for anything slower than the last bound.
+	buckets    []int64
+	latencySum float64
+}
+
+// metrics holds the cumulative counters and current gauges exposed in
+// Prometheus text format on /metrics.
+type metrics struct {
+	mu             sync.Mutex
+	operations     map[models.Operation]*operationMetrics
+	workers        int
+	desiredWorkers int
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		operations: map[models.Operation]*operationMetrics{},
+	}
+}
+
+func (m *metrics) operation(name models.Operation) *operationMetrics {
+	om, ok := m.operations[name]
+	if !ok {
+		om = &operationMetrics{
+			errors:  map[ErrorClass]int64{},
+			buckets: make([]int64, len(latencyBuckets)+1),
+		}
+		m.operations[name] = om
+	}
+
+	return om
+}
+
+func (m *metrics) observe(r Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	om := m.operation(r.Operation)
+	om.requests++
+	om.retries += int64(r.Retries)
+
+	if r.Error != "" {
+		om.errors[r.Error]++
+		return
+	}
+
+	seconds := r.Latency.Seconds()
+	i, _ := slices.BinarySearch(latencyBuckets, seconds)
+	om.buckets[i]++
+	om.latencySum += seconds
+}
+
+func (m *metrics) setApdex(operation models.Operation, score float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.operation(operation).apdex = score
+}
+
+func (m *metrics) setWorkers(current, desired int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.workers = current
+	m.desiredWorkers = desired
+}
+
+// write outputs the metrics in Prometheus text format, labelled with
+// the given region.
+func (m *metrics) write(w io.Writer, region string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	region = strconv.Quote(region)
+	operations := slices.Sorted(maps.Keys(m.operations))
+
+	fmt.Fprintln(w, "# HELP scale_spin_requests_total Requests made, including failed requests.")
+	fmt.Fprintln(w, "# TYPE scale_spin_requests_total counter")
+	for _, op := range operations {
+		fmt.Fprintf(w, "scale_spin_requests_total{region=%s,operation=%q} %d\n", region, op, m.operations[op].requests)
+	}
+
+	fmt.Fprintln(w, "# HELP scale_spin_request_retries_total Transactions retried due to contention.")
+	fmt.Fprintln(w, "# TYPE scale_spin_request_retries_total counter")
+	for _, op := range operations {
+		fmt.Fprintf(w, "scale_spin_request_retries_total{region=%s,operation=%q} %d\n", region, op, m.operations[op].retries)
+	}
+
+	fmt.Fprintln(w, "# HELP scale_spin_request_errors_total Failed requests by error class.")
+	fmt.Fprintln(w, "# TYPE scale_spin_request_errors_total counter")
+	for _, op := range operations {
+		for _, class := range errorClasses {
+			fmt.Fprintf(w, "scale_spin_request_errors_total{region=%s,operation=%q,class=%q} %d\n", region, op, class, m.operations[op].errors[class])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP scale_spin_request_duration_seconds Latency of successful requests.")
+	fmt.Fprintln(w, "# TYPE scale_spin_request_duration_seconds histogram")
+	for _, op := range operations {
+		om := m.operations[op]
+
+		var count int64
+		for i, bound := range latencyBuckets {
+			count += om.buckets[i]
+			fmt.Fprintf(w, "scale_spin_request_duration_seconds_bucket{region=%s,operation=%q,le=%q} %d\n", region, op, strconv.FormatFloat(bound, 'f', -1, 64), count)
+		}
+		count += om.buckets[len(latencyBuckets)]
+
+		fmt.Fprintf(w, "scale_spin_request_duration_seconds_bucket{region=%s,operation=%q,le=\"+Inf\"} %d\n", region, op, count)
+		fmt.Fprintf(w, "scale_spin_request_duration_seconds_sum{region=%s,operation=%q} %g\n", region, op, om.latencySum)
+		fmt.Fprintf(w, "scale_spin_request_duration_seconds_count{region=%s,operation=%q} %d\n", region, op, count)
+	}
+
+	fmt.Fprintln(w, "# HELP scale_spin_apdex Apdex score of recent requests.")
+	fmt.Fprintln(w, "# TYPE scale_spin_apdex gauge")
+	for _, op := range operations {
+		fmt.Fprintf(w, "scale_spin_apdex{region=%s,operation=%q} %g\n", region, op, m.operations[op].apdex)
+	}
+
+	fmt.Fprintln(w, "# HELP scale_spin_workers Workers currently running.")
+	fmt.Fprintln(w, "# TYPE scale_spin_workers gauge")
+	fmt.Fprintf(w, "scale_spin_workers{region=%s} %d\n", region, m.workers)
+
+	fmt.Fprintln(w, "# HELP scale_spin_workers_desired Workers requested by the workload table.")
+	fmt.Fprintln(w, "# TYPE scale_spin_workers_desired gauge")
+	fmt.Fprintf(w, "scale_spin_workers_desired{region=%s} %d\n", region, m.desiredWorkers)
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	m := newMetrics()
+	m.observe(Result{Operation: models.OperationTransfer, Latency: time.Millisecond * 5, Retries: 2})
+	m.observe(Result{Operation: models.OperationTransfer, Latency: time.Millisecond * 20})
+	m.observe(Result{Operation: models.OperationTransfer, Latency: time.Second * 2})
+	m.observe(Result{Operation: models.OperationTransfer, Error: ErrorClassTimeout})
+	m.setApdex(models.OperationTransfer, 0.5)
+	m.setWorkers(3, 4)
+
+	var sb strings.Builder
+	m.write(&sb, `eu"west`)
+	lines := strings.Split(sb.String(), "\n")
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{
+			name: "help and type",
+			want: []string{
+				"# HELP scale_spin_requests_total Requests made, including failed requests.",
+				"# TYPE scale_spin_requests_total counter",
+				"# TYPE scale_spin_request_duration_seconds histogram",
+				"# TYPE scale_spin_apdex gauge",
+			},
+		},
+		{
+			name: "counters include failed requests",
+			want: []string{
+				`scale_spin_requests_total{region="eu\"west",operation="transfer"} 4`,
+				`scale_spin_request_retries_total{region="eu\"west",operation="transfer"} 2`,
+				`scale_spin_request_errors_total{region="eu\"west",operation="transfer",class="timeout"} 1`,
+				`scale_spin_request_errors_total{region="eu\"west",operation="transfer",class="other"} 0`,
+			},
+		},
+		{
+			name: "buckets are cumulative",
+			want: []string{
+				`scale_spin_request_duration_seconds_bucket{region="eu\"west",operation="transfer",le="0.005"} 1`,
+				`scale_spin_request_duration_seconds_bucket{region="eu\"west",operation="transfer",le="0.01"} 1`,
+				`scale_spin_request_duration_seconds_bucket{region="eu\"west",operation="transfer",le="0.02"} 2`,
+				`scale_spin_request_duration_seconds_bucket{region="eu\"west",operation="transfer",le="1"} 2`,
+				`scale_spin_request_duration_seconds_bucket{region="eu\"west",operation="transfer",le="+Inf"} 3`,
+				`scale_spin_request_duration_seconds_count{region="eu\"west",operation="transfer"} 3`,
+			},
+		},
+		{
+			name: "gauges",
+			want: []string{
+				`scale_spin_apdex{region="eu\"west",operation="transfer"} 0.5`,
+				`scale_spin_workers{region="eu\"west"} 3`,
+				`scale_spin_workers_desired{region="eu\"west"} 4`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.want {
+				assert.Contains(t, lines, want)
+			}
+		})
+	}
+}
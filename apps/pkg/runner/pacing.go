@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+)
+
+// pacing determines the time between each worker's requests.
+type pacing struct {
+	rate      int
+	thinkTime models.ThinkTime
+}
+
+func newPacing(rate int, thinkTime models.ThinkTime) (*pacing, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive, got %d", rate)
+	}
+
+	switch thinkTime {
+	case models.ThinkTimeFixed, models.ThinkTimeUniform, models.ThinkTimeExponential:
+	default:
+		return nil, fmt.Errorf("unsupported think time: %q", thinkTime)
+	}
+
+	return &pacing{
+		rate:      rate,
+		thinkTime: thinkTime,
+	}, nil
+}
+
+// interval returns the time to wait before the next request.
+func (p *pacing) interval() time.Duration {
+	mean := float64(time.Second) / float64(p.rate)
+
+	switch p.thinkTime {
+	case models.ThinkTimeUniform:
+		return time.Duration(rand.Float64() * 2 * mean)
+	case models.ThinkTimeExponential:
+		return time.Duration(rand.ExpFloat64() * mean)
+	default:
+		return time.Duration(mean)
+	}
+}
+
+// maxInFlight returns the number of requests a worker can have in flight
+// in open mode before further requests are counted as missed.
+func (p *pacing) maxInFlight() int64 {
+	return max(int64(float64(p.rate)*requestTimeout.Seconds()), 1)
+}
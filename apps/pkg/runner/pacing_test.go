@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPacing(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      int
+		thinkTime models.ThinkTime
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			rate:      10,
+			thinkTime: models.ThinkTimeExponential,
+		},
+		{
+			name:      "zero rate",
+			rate:      0,
+			thinkTime: models.ThinkTimeFixed,
+			wantErr:   "rate must be positive, got 0",
+		},
+		{
+			name:      "unknown think time",
+			rate:      10,
+			thinkTime: "gaussian",
+			wantErr:   `unsupported think time: "gaussian"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPacing(tt.rate, tt.thinkTime)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPacingInterval(t *testing.T) {
+	const samples = 10000
+
+	tests := []struct {
+		name      string
+		thinkTime models.ThinkTime
+		min       time.Duration
+		max       time.Duration
+	}{
+		{
+			name:      "fixed",
+			thinkTime: models.ThinkTimeFixed,
+			min:       time.Millisecond * 100,
+			max:       time.Millisecond * 100,
+		},
+		{
+			name:      "uniform",
+			thinkTime: models.ThinkTimeUniform,
+			min:       0,
+			max:       time.Millisecond * 200,
+		},
+		{
+			name:      "exponential",
+			thinkTime: models.ThinkTimeExponential,
+			min:       0,
+			max:       time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPacing(10, tt.thinkTime)
+			assert.NoError(t, err)
+
+			var total time.Duration
+			for range samples {
+				d := p.interval()
+				assert.GreaterOrEqual(t, d, tt.min)
+				assert.LessOrEqual(t, d, tt.max)
+				total += d
+			}
+
+			// Every think time averages out at the rate.
+			assert.InDelta(t, time.Millisecond*100, total/samples, float64(time.Millisecond*10))
+		})
+	}
+}
+
+func TestPacingMaxInFlight(t *testing.T) {
+	cases := []struct {
+		rate int
+		want int64
+	}{
+		{rate: 1, want: 1},
+		{rate: 50, want: 50},
+	}
+
+	for _, c := range cases {
+		p, err := newPacing(c.rate, models.ThinkTimeFixed)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, p.maxInFlight())
+	}
+}
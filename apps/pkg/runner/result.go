@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ErrorClass string
+
+const (
+	// The request exceeded its deadline or was cancelled by the database.
+	ErrorClassTimeout ErrorClass = "timeout"
+
+	// The database aborted the transaction due to contention (40001).
+	ErrorClassSerialization ErrorClass = "serialization"
+
+	// The request couldn't reach the database.
+	ErrorClassConnection ErrorClass = "connection"
+
+	// Anything else.
+	ErrorClassOther ErrorClass = "other"
+)
+
+var errorClasses = []ErrorClass{
+	ErrorClassTimeout,
+	ErrorClassSerialization,
+	ErrorClassConnection,
+	ErrorClassOther,
+}
+
+// Result is the outcome of a single request. Error is empty for
+// successful requests and Retries is the number of times a transaction
+// was retried due to contention.
+type Result struct {
+	Operation models.Operation
+	Latency   time.Duration
+	Error     ErrorClass
+	Retries   int
+}
+
+func classify(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "40001":
+			return ErrorClassSerialization
+		case pgErr.Code == "57014":
+			return ErrorClassTimeout
+		case strings.HasPrefix(pgErr.Code, "08"):
+			return ErrorClassConnection
+		default:
+			return ErrorClassOther
+		}
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
+		return ErrorClassTimeout
+	case errors.As(err, &connectErr), errors.Is(err, driver.ErrBadConn), errors.As(err, &netErr):
+		return ErrorClassConnection
+	default:
+		return ErrorClassOther
+	}
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{
+			name: "no error",
+			want: "",
+		},
+		{
+			name: "serialization failure",
+			err:  &pgconn.PgError{Code: "40001"},
+			want: ErrorClassSerialization,
+		},
+		{
+			name: "wrapped serialization failure",
+			err:  fmt.Errorf("making request: %w", &pgconn.PgError{Code: "40001"}),
+			want: ErrorClassSerialization,
+		},
+		{
+			name: "query cancelled",
+			err:  &pgconn.PgError{Code: "57014"},
+			want: ErrorClassTimeout,
+		},
+		{
+			name: "connection failure",
+			err:  &pgconn.PgError{Code: "08006"},
+			want: ErrorClassConnection,
+		},
+		{
+			name: "other database error",
+			err:  &pgconn.PgError{Code: "42P01"},
+			want: ErrorClassOther,
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("making request: %w", context.DeadlineExceeded),
+			want: ErrorClassTimeout,
+		},
+		{
+			name: "network error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: ErrorClassConnection,
+		},
+		{
+			name: "bad connection",
+			err:  driver.ErrBadConn,
+			want: ErrorClassConnection,
+		},
+		{
+			name: "anything else",
+			err:  errors.New("boom"),
+			want: ErrorClassOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classify(tt.err))
+		})
+	}
+}
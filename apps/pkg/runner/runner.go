@@ -2,70 +2,250 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codingconcepts/errhandler"
 	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/histogram"
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
 	"github.com/codingconcepts/scale-spin/apps/pkg/repo"
 )
 
 type Runner struct {
-	repo   repo.Repo
-	region string
-
-	taken chan time.Duration
-
-	lastScoreMu sync.RWMutex
-	lastScore   float64
+	repo     repo.Repo
+	repoMode string
+	region   string
+	mode     LoadMode
+	model    apdex.Model
+	pacing   atomic.Pointer[pacing]
+	mix      atomic.Pointer[models.Mix]
+	keys     atomic.Pointer[models.KeyDistribution]
+	schedule *schedule
+
+	results chan Result
+	metrics *metrics
+	mux     *http.ServeMux
+
+	lastScoreMu   sync.RWMutex
+	lastScore     float64
+	lastWindows   []WindowScore
+	lastOpWindows map[models.Operation][]WindowScore
+	lastErrorRate float64
+	lastErrors    map[ErrorClass]int
+	lastMissed    int64
+	lastLate      int64
+	lastStats     histogram.Percentiles
+	lastOpStats   map[models.Operation]histogram.Percentiles
 
 	workersMu sync.RWMutex
 	workers   []*Worker
+
+	// The accounts requests are made against, fetched when the first
+	// worker starts and shared by every worker, so they all hit the
+	// same hot keys.
+	//
+	// Guarded by workersMu.
+	ids []any
 }
 
-func New(repo repo.Repo, region string) *Runner {
-	return &Runner{
-		repo:   repo,
-		region: region,
-		taken:  make(chan time.Duration, 1000),
+func New(repo repo.Repo, repoMode, region string, mode LoadMode, model apdex.Model) *Runner {
+	rr := Runner{
+		repo:     repo,
+		repoMode: repoMode,
+		region:   region,
+		mode:     mode,
+		model:    model,
+		schedule: &schedule{},
+		results:  make(chan Result, 1000),
+		metrics:  newMetrics(),
+		mux:      http.NewServeMux(),
 	}
+
+	rr.mux.Handle("GET /healthz", errhandler.Wrap(rr.handleHealthCheck))
+	rr.mux.Handle("GET /apdex", errhandler.Wrap(rr.getApdex))
+	rr.mux.Handle("GET /stats", errhandler.Wrap(rr.getStats))
+	rr.mux.Handle("GET /metrics", errhandler.Wrap(rr.getMetrics))
+
+	rr.pacing.Store(&pacing{rate: 100, thinkTime: models.ThinkTimeFixed})
+	rr.mix.Store(&models.Mix{models.OperationTransfer: 100})
+	rr.keys.Store(&models.KeyDistribution{Kind: models.KeysUniform})
+
+	return &rr
 }
 
 func (rr *Runner) Run() {
 	logTicks := time.Tick(time.Second)
 
-	latencies := newThreadUnsafeRing[time.Duration](1000)
 	requestsMade := 0
+	retriesMade := 0
+	errorsMade := map[ErrorClass]int{}
+
+	// Latencies of successful requests made in the current reporting
+	// window, reset each time the window is reported.
+	latencies := histogram.New()
+	opLatencies := map[models.Operation]*histogram.Histogram{}
+
+	// Apdex scores of all requests, and of each operation, for every
+	// second of the longest window.
+	seconds := int(slices.Max(apdexWindows) / time.Second)
+	scores := apdex.NewSlidingWindow(rr.model, seconds)
+	operationScores := map[models.Operation]*apdex.SlidingWindow{}
 
 	go rr.pollForWorkers()
 
 	for {
 		select {
-		case taken := <-rr.taken:
+		case result := <-rr.results:
 			requestsMade++
-			latencies.add(taken)
+			retriesMade += result.Retries
+			rr.metrics.observe(result)
+
+			opScores, ok := operationScores[result.Operation]
+			if !ok {
+				opScores = apdex.NewSlidingWindow(rr.model, seconds)
+				operationScores[result.Operation] = opScores
+				opLatencies[result.Operation] = histogram.New()
+			}
+
+			if result.Error != "" {
+				errorsMade[result.Error]++
+				scores.AddError()
+				opScores.AddError()
+			} else {
+				latencies.Record(result.Latency)
+				opLatencies[result.Operation].Record(result.Latency)
+				scores.Add(result.Latency)
+				opScores.Add(result.Latency)
+			}
 
 		case <-logTicks:
-			latencySlice := latencies.slice()
-			rr.lastScore = apdex.Score(latencySlice)
-
-			log.Printf("score: %.2f, rps: %d, workers: %d", rr.lastScore, requestsMade, len(rr.workers))
+			scores.Advance()
+
+			windows := make([]WindowScore, len(apdexWindows))
+			for i, window := range apdexWindows {
+				windows[i] = newWindowScore(window, scores.Snapshot(int(window/time.Second)))
+			}
+
+			opWindows := map[models.Operation][]WindowScore{}
+			for op, opScores := range operationScores {
+				opScores.Advance()
+				for _, window := range apdexWindows {
+					opWindows[op] = append(opWindows[op], newWindowScore(window, opScores.Snapshot(int(window/time.Second))))
+				}
+				rr.metrics.setApdex(op, opWindows[op][0].Score)
+			}
+
+			opStats := map[models.Operation]histogram.Percentiles{}
+			for op, h := range opLatencies {
+				opStats[op] = h.Percentiles()
+				h.Reset()
+			}
+
+			score := windows[0].Score
+			missed := rr.schedule.missed.Swap(0)
+			late := rr.schedule.late.Swap(0)
+			stats := latencies.Percentiles()
+
+			rr.lastScoreMu.Lock()
+			rr.lastScore = score
+			rr.lastWindows = windows
+			rr.lastOpWindows = opWindows
+			rr.lastErrorRate = windows[0].ErrorRate()
+			rr.lastErrors = maps.Clone(errorsMade)
+			rr.lastMissed = missed
+			rr.lastLate = late
+			rr.lastStats = stats
+			rr.lastOpStats = opStats
+			rr.lastScoreMu.Unlock()
+
+			log.Printf("score: %.2f, rps: %d, workers: %d, p50: %.1fms, p99: %.1fms, max: %.1fms, errors: %s, retries: %d, missed: %d, late: %d", score, requestsMade, len(rr.workers), stats.P50, stats.P99, stats.Max, formatErrors(errorsMade), retriesMade, missed, late)
 			requestsMade = 0
+			retriesMade = 0
+			clear(errorsMade)
+			latencies.Reset()
 		}
 	}
 }
 
+func formatErrors(counts map[ErrorClass]int) string {
+	var total int
+	var parts []string
+	for _, class := range errorClasses {
+		if counts[class] > 0 {
+			total += counts[class]
+			parts = append(parts, fmt.Sprintf("%s: %d", class, counts[class]))
+		}
+	}
+
+	if total == 0 {
+		return "0"
+	}
+
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
 func (rr *Runner) pollForWorkers() {
 	for range time.Tick(time.Second * 5) {
-		workers, err := rr.repo.FetchWorkers(context.Background(), rr.region)
+		workload, err := rr.repo.FetchWorkload(context.Background(), rr.region)
 		if err != nil {
-			log.Printf("error fetching worker count: %v", err)
+			log.Printf("error fetching workload: %v", err)
 			continue
 		}
 
-		rr.setWorkers(workers)
+		rr.setPacing(workload.Rate, workload.ThinkTime)
+		rr.setMix(workload.Mix)
+		rr.setKeys(workload.KeyDistribution)
+		rr.setWorkers(workload.Workers)
+	}
+}
+
+// setPacing updates the request rate and think time of all workers.
+func (rr *Runner) setPacing(rate int, thinkTime models.ThinkTime) {
+	p, err := newPacing(rate, thinkTime)
+	if err != nil {
+		log.Printf("ignoring invalid pacing: %v", err)
+		return
+	}
+
+	if current := rr.pacing.Load(); *current != *p {
+		log.Printf("rate: %d, think time: %s", rate, thinkTime)
+		rr.pacing.Store(p)
+	}
+}
+
+// setMix updates the operations made by all workers.
+func (rr *Runner) setMix(s string) {
+	mix, err := models.ParseMix(s)
+	if err != nil {
+		log.Printf("ignoring invalid mix: %v", err)
+		return
+	}
+
+	if current := rr.mix.Load(); current.String() != mix.String() {
+		log.Printf("mix: %s", mix)
+		rr.mix.Store(&mix)
+	}
+}
+
+// setKeys updates the distribution of accounts used by all workers.
+func (rr *Runner) setKeys(s string) {
+	keys, err := models.ParseKeyDistribution(s)
+	if err != nil {
+		log.Printf("ignoring invalid key distribution: %v", err)
+		return
+	}
+
+	if current := rr.keys.Load(); *current != keys {
+		log.Printf("key distribution: %s", keys)
+		rr.keys.Store(&keys)
 	}
 }
 
@@ -73,28 +253,56 @@ func (rr *Runner) setWorkers(count int) {
 	rr.workersMu.Lock()
 	defer rr.workersMu.Unlock()
 
+	rr.metrics.setWorkers(len(rr.workers), count)
+
 	for len(rr.workers) != count {
 		time.Sleep(time.Millisecond * 100)
 		log.Printf("workers: %d / desired: %d", len(rr.workers), count)
 
 		if len(rr.workers) < count {
-			rr.addWorker()
+			if err := rr.addWorker(); err != nil {
+				log.Printf("error adding worker: %v", err)
+				return
+			}
 		} else {
 			rr.removeWorker()
 		}
+
+		rr.metrics.setWorkers(len(rr.workers), count)
 	}
 }
 
 // addWorker starts a new worker thread.
 //
 // IMPORTANT: Caller must hold an exclusive lock to rr.workersMu before invoking.
-func (rr *Runner) addWorker() {
+func (rr *Runner) addWorker() error {
+	if rr.ids == nil {
+		ids, err := rr.fetchIDs()
+		if err != nil {
+			return fmt.Errorf("fetching ids: %w", err)
+		}
+
+		if len(ids) == 0 {
+			return fmt.Errorf("no ids found")
+		}
+
+		rr.ids = ids
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	w := NewWorker(ctx, cancel, rr.repo, rr.taken)
+	w := NewWorker(ctx, cancel, rr.repo, rr.ids, rr.mode, &rr.pacing, &rr.mix, &rr.keys, rr.schedule, rr.results)
 	rr.workers = append(rr.workers, w)
 
 	go w.run()
+	return nil
+}
+
+func (rr *Runner) fetchIDs() ([]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return rr.repo.FetchIDs(ctx)
 }
 
 // removeWorker stops a worker thread.
@@ -114,21 +322,41 @@ func (rr *Runner) removeWorker() {
 	rr.workers = rr.workers[:lastIdx]
 }
 
-func (r *Runner) Serve() error {
-	mux := http.NewServeMux()
-	mux.Handle("GET /healthz", errhandler.Wrap(r.handleHealthCheck))
-	mux.Handle("GET /apdex", errhandler.Wrap(r.getApdex))
+// Handle serves an additional endpoint alongside the runner's own. It
+// must be called before Serve.
+func (rr *Runner) Handle(pattern string, handler http.Handler) {
+	rr.mux.Handle(pattern, handler)
+}
 
-	server := &http.Server{Addr: "0.0.0.0:8080", Handler: mux}
+func (rr *Runner) Serve() error {
+	server := &http.Server{Addr: "0.0.0.0:8080", Handler: rr.mux}
 	return server.ListenAndServe()
 }
 
+type healthCheckResponse struct {
+	Status string `json:"status"`
+	Region string `json:"region"`
+	Repo   string `json:"repo"`
+}
+
 func (rr *Runner) handleHealthCheck(w http.ResponseWriter, r *http.Request) error {
-	return errhandler.SendString(w, "OK")
+	resp := healthCheckResponse{
+		Status: "OK",
+		Region: rr.region,
+		Repo:   rr.repoMode,
+	}
+
+	return errhandler.SendJSON(w, resp)
 }
 
 type getApdexResponse struct {
-	Score float64 `json:"score"`
+	Score      float64                            `json:"score"`
+	Windows    []WindowScore                      `json:"windows"`
+	Operations map[models.Operation][]WindowScore `json:"operations"`
+	ErrorRate  float64                            `json:"error_rate"`
+	Errors     map[ErrorClass]int                 `json:"errors"`
+	Missed     int64                              `json:"missed"`
+	Late       int64                              `json:"late"`
 }
 
 func (rr *Runner) getApdex(w http.ResponseWriter, r *http.Request) error {
@@ -136,8 +364,42 @@ func (rr *Runner) getApdex(w http.ResponseWriter, r *http.Request) error {
 	defer rr.lastScoreMu.RUnlock()
 
 	resp := getApdexResponse{
-		Score: rr.lastScore,
+		Score:      rr.lastScore,
+		Windows:    rr.lastWindows,
+		Operations: rr.lastOpWindows,
+		ErrorRate:  rr.lastErrorRate,
+		Errors:     rr.lastErrors,
+		Missed:     rr.lastMissed,
+		Late:       rr.lastLate,
+	}
+
+	return errhandler.SendJSON(w, resp)
+}
+
+type getStatsResponse struct {
+	histogram.Percentiles
+	Operations map[models.Operation]histogram.Percentiles `json:"operations"`
+}
+
+// getStats returns the latency percentiles of successful requests made
+// in the last reporting window, overall and for each operation.
+func (rr *Runner) getStats(w http.ResponseWriter, r *http.Request) error {
+	rr.lastScoreMu.RLock()
+	defer rr.lastScoreMu.RUnlock()
+
+	resp := getStatsResponse{
+		Percentiles: rr.lastStats,
+		Operations:  rr.lastOpStats,
 	}
 
 	return errhandler.SendJSON(w, resp)
 }
+
+// getMetrics returns request, error, latency, worker and Apdex metrics
+// in Prometheus text format.
+func (rr *Runner) getMetrics(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	rr.metrics.write(w, rr.region)
+
+	return nil
+}
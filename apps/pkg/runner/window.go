@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+)
+
+// apdexWindows are the wall-clock windows Apdex scores are reported
+// over. The longest matches the game's default round length.
+var apdexWindows = []time.Duration{
+	time.Second * 10,
+	time.Minute,
+	time.Minute * 5,
+	time.Minute * 10,
+}
+
+// WindowScore is the Apdex score of the requests made within a window,
+// along with the number of requests it was calculated from and how they
+// were scored, so that windows can be aggregated across regions.
+type WindowScore struct {
+	Window  string  `json:"window"`
+	Score   float64 `json:"score"`
+	Samples int64   `json:"samples"`
+	apdex.Summary
+}
+
+func newWindowScore(window time.Duration, s apdex.Summary) WindowScore {
+	return WindowScore{
+		Window:  formatWindow(window),
+		Score:   s.Score(),
+		Samples: s.Samples(),
+		Summary: s,
+	}
+}
+
+// formatWindow returns a window as 10s, 1m or 1h rather than 1m0s.
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
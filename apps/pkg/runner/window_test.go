@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWindowScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  time.Duration
+		summary apdex.Summary
+		want    WindowScore
+	}{
+		{
+			name:   "seconds",
+			window: time.Second * 10,
+			summary: apdex.Summary{
+				Satisfied: 6, Tolerating: 2, Frustrated: 2, Errors: 1, Total: 7,
+			},
+			want: WindowScore{
+				Window:  "10s",
+				Score:   0.7,
+				Samples: 10,
+				Summary: apdex.Summary{Satisfied: 6, Tolerating: 2, Frustrated: 2, Errors: 1, Total: 7},
+			},
+		},
+		{
+			name:   "minutes",
+			window: time.Minute * 5,
+			want:   WindowScore{Window: "5m"},
+		},
+		{
+			name:   "hours",
+			window: time.Hour,
+			want:   WindowScore{Window: "1h"},
+		},
+		{
+			name:   "mixed units",
+			window: time.Second * 90,
+			want:   WindowScore{Window: "1m30s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newWindowScore(tt.window, tt.summary))
+		})
+	}
+}
@@ -5,80 +5,217 @@ import (
 	"fmt"
 	"log"
 	"math/rand/v2"
+	"sync/atomic"
 	"time"
 
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
 	"github.com/codingconcepts/scale-spin/apps/pkg/repo"
-	"github.com/samber/lo"
 )
 
+type LoadMode string
+
+const (
+	// Each worker waits for a request to complete before making the next,
+	// so a slow database reduces the number of requests made.
+	LoadModeClosed LoadMode = "closed"
+
+	// Each worker schedules requests at a fixed arrival rate, regardless
+	// of whether previous requests have completed.
+	LoadModeOpen LoadMode = "open"
+)
+
+func ParseLoadMode(s string) (LoadMode, error) {
+	switch m := LoadMode(s); m {
+	case LoadModeClosed, LoadModeOpen:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unsupported load mode: %q", s)
+	}
+}
+
+const requestTimeout = time.Second
+
+// scanLimit is the number of accounts read by each scan.
+const scanLimit = 100
+
+// schedule counts requests that couldn't be made on time in open mode.
+type schedule struct {
+	// Requests not made because the worker already had the maximum
+	// number of requests in flight.
+	missed atomic.Int64
+
+	// Requests made later than one interval after their intended start.
+	late atomic.Int64
+}
+
 type Worker struct {
-	repo   repo.Repo
-	taken  chan time.Duration
-	ctx    context.Context
-	cancel context.CancelFunc
+	repo     repo.Repo
+	ids      []any
+	mode     LoadMode
+	pacing   *atomic.Pointer[pacing]
+	mix      *atomic.Pointer[models.Mix]
+	keys     *atomic.Pointer[models.KeyDistribution]
+	schedule *schedule
+	results  chan Result
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	// Picks from ids using the current key distribution,
+	// replaced whenever the distribution changes.
+	picker atomic.Pointer[models.KeyPicker]
 }
 
-func NewWorker(ctx context.Context, cancel context.CancelFunc, repo repo.Repo, taken chan time.Duration) *Worker {
+func NewWorker(ctx context.Context, cancel context.CancelFunc, repo repo.Repo, ids []any, mode LoadMode, pacing *atomic.Pointer[pacing], mix *atomic.Pointer[models.Mix], keys *atomic.Pointer[models.KeyDistribution], schedule *schedule, results chan Result) *Worker {
 	return &Worker{
-		repo:   repo,
-		taken:  taken,
-		ctx:    ctx,
-		cancel: cancel,
+		repo:     repo,
+		ids:      ids,
+		mode:     mode,
+		pacing:   pacing,
+		mix:      mix,
+		keys:     keys,
+		schedule: schedule,
+		results:  results,
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
 func (w *Worker) run() error {
-	ids, err := w.fetchIDs()
-	if err != nil {
-		return fmt.Errorf("fetching ids: %w", err)
+	if len(w.ids) == 0 {
+		return fmt.Errorf("no ids found")
 	}
 
-	if len(ids) == 0 {
-		return fmt.Errorf("no ids found")
+	if w.mode == LoadModeOpen {
+		w.runOpen(w.ids)
+		return nil
 	}
 
-	requestTicks := time.Tick(time.Second / 100)
+	w.runClosed(w.ids)
+	return nil
+}
+
+// runClosed makes one request at a time, waiting for each to complete.
+// If a request takes longer than the think time, the next starts as soon
+// as it completes.
+func (w *Worker) runClosed(ids []any) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-requestTicks:
-			pair := lo.Samples(ids, 2)
-			if len(pair) < 2 {
-				log.Printf("need at least 2 ids, got %d (of a total %d)", len(pair), len(ids))
-				continue
-			}
+		case <-timer.C:
+		case <-w.ctx.Done():
+			return
+		}
 
-			amount := rand.Float64() * 100
+		start := time.Now()
+		w.request(ids, start)
+		timer.Reset(time.Until(start.Add(w.pacing.Load().interval())))
+	}
+}
 
-			taken, err := w.makeRequest(pair[0], pair[1], amount)
-			if err != nil {
-				log.Printf("error making request: %v", err)
-			}
+// runOpen starts requests at their scheduled time without waiting for
+// earlier requests to complete. Latency is measured from each request's
+// intended start, so time spent waiting on the schedule is included.
+func (w *Worker) runOpen(ids []any) {
+	var inFlight atomic.Int64
 
-			w.taken <- taken
+	next := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
+	for {
+		select {
+		case <-timer.C:
 		case <-w.ctx.Done():
-			return nil
+			return
+		}
+
+		p := w.pacing.Load()
+		interval := p.interval()
+
+		intended := next
+		next = next.Add(interval)
+		timer.Reset(time.Until(next))
+
+		if time.Since(intended) > interval {
+			w.schedule.late.Add(1)
 		}
+
+		if inFlight.Load() >= p.maxInFlight() {
+			w.schedule.missed.Add(1)
+			continue
+		}
+
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Add(-1)
+			w.request(ids, intended)
+		}()
 	}
 }
 
-func (w *Worker) fetchIDs() ([]any, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
+// request makes an operation picked at random from the mix, reporting
+// the time taken since start.
+func (w *Worker) request(ids []any, start time.Time) {
+	mix := *w.mix.Load()
+	op := mix.Pick(rand.IntN(mix.Total()))
+
+	taken, retries, err := w.makeRequest(start, op, ids)
+
+	class := classify(err)
+	if class == ErrorClassOther {
+		log.Printf("error making %s request: %v", op, err)
+	}
 
-	return w.repo.FetchIDs(ctx)
+	w.results <- Result{
+		Operation: op,
+		Latency:   taken,
+		Error:     class,
+		Retries:   retries,
+	}
 }
 
-func (w *Worker) makeRequest(idFrom, idTo any, amount float64) (taken time.Duration, err error) {
-	start := time.Now()
+// keyPicker returns a picker for the current key distribution.
+func (w *Worker) keyPicker(ids []any) *models.KeyPicker {
+	keys := *w.keys.Load()
+
+	p := w.picker.Load()
+	if p == nil || p.Distribution() != keys {
+		p = keys.NewPicker(len(ids))
+		w.picker.Store(p)
+	}
+
+	return p
+}
+
+func (w *Worker) makeRequest(start time.Time, op models.Operation, ids []any) (taken time.Duration, retries int, err error) {
 	defer func() {
 		taken = time.Since(start)
 	}()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 
-	err = w.repo.MakeRequest(ctx, idFrom, idTo, amount)
+	keys := w.keyPicker(ids)
+
+	switch op {
+	case models.OperationRead:
+		_, err = w.repo.ReadBalance(ctx, ids[keys.Pick(rand.Float64())])
+
+	case models.OperationInsert:
+		err = w.repo.InsertTransaction(ctx, ids[keys.Pick(rand.Float64())], rand.Float64()*100)
+
+	case models.OperationScan:
+		_, err = w.repo.ScanAccounts(ctx, ids[keys.Pick(rand.Float64())], scanLimit)
+
+	default:
+		if len(ids) < 2 {
+			return 0, 0, fmt.Errorf("need at least 2 ids, got %d", len(ids))
+		}
+		from, to := keys.PickPair(rand.Float64(), rand.Float64())
+		retries, err = w.repo.Transfer(ctx, ids[from], ids[to], rand.Float64()*100)
+	}
+
 	return
 }
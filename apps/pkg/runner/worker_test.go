@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testIDs = []any{1, 2, 3}
+
+// fakeRepo records the last operation made against it and, if release
+// is set, holds every request until it's closed, like a database that
+// has stopped responding.
+type fakeRepo struct {
+	started atomic.Int64
+	made    atomic.Value
+	release chan struct{}
+}
+
+func (r *fakeRepo) request(ctx context.Context, op models.Operation) {
+	r.started.Add(1)
+	r.made.Store(op)
+
+	if r.release == nil {
+		return
+	}
+
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+	}
+}
+
+func (r *fakeRepo) FetchWorkload(ctx context.Context, region string) (models.Workload, error) {
+	return models.Workload{}, nil
+}
+
+func (r *fakeRepo) FetchIDs(ctx context.Context) ([]any, error) {
+	return testIDs, nil
+}
+
+func (r *fakeRepo) ReadBalance(ctx context.Context, id any) (float64, error) {
+	r.request(ctx, models.OperationRead)
+	return 0, nil
+}
+
+func (r *fakeRepo) Transfer(ctx context.Context, idFrom, idTo any, amount float64) (int, error) {
+	r.request(ctx, models.OperationTransfer)
+	return 1, nil
+}
+
+func (r *fakeRepo) InsertTransaction(ctx context.Context, id any, amount float64) error {
+	r.request(ctx, models.OperationInsert)
+	return nil
+}
+
+func (r *fakeRepo) ScanAccounts(ctx context.Context, idFrom any, limit int) (int, error) {
+	r.request(ctx, models.OperationScan)
+	return 0, nil
+}
+
+// fixedPacing returns pacing for a fixed number of requests per second.
+func fixedPacing(t *testing.T, rate int) *atomic.Pointer[pacing] {
+	t.Helper()
+
+	p, err := newPacing(rate, models.ThinkTimeFixed)
+	assert.NoError(t, err)
+
+	var ptr atomic.Pointer[pacing]
+	ptr.Store(p)
+
+	return &ptr
+}
+
+// mixOf returns a mix parsed from s.
+func mixOf(t *testing.T, s string) *atomic.Pointer[models.Mix] {
+	t.Helper()
+
+	mix, err := models.ParseMix(s)
+	assert.NoError(t, err)
+
+	var ptr atomic.Pointer[models.Mix]
+	ptr.Store(&mix)
+
+	return &ptr
+}
+
+// uniformKeys returns a uniform key distribution.
+func uniformKeys(t *testing.T) *atomic.Pointer[models.KeyDistribution] {
+	t.Helper()
+
+	keys, err := models.ParseKeyDistribution("uniform")
+	assert.NoError(t, err)
+
+	var ptr atomic.Pointer[models.KeyDistribution]
+	ptr.Store(&keys)
+
+	return &ptr
+}
+
+func TestWorkerLoadMode(t *testing.T) {
+	tests := []struct {
+		mode    LoadMode
+		wantMin int64
+		wantMax int64
+	}{
+		// Waits for the first request, however long it takes.
+		{mode: LoadModeClosed, wantMin: 1, wantMax: 1},
+
+		// Keeps starting requests on schedule (every 10ms).
+		{mode: LoadModeOpen, wantMin: 5, wantMax: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			repo := &fakeRepo{release: make(chan struct{})}
+			results := make(chan Result, 100)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			w := NewWorker(ctx, cancel, repo, testIDs, tt.mode, fixedPacing(t, 100), mixOf(t, "transfer=1"), uniformKeys(t), &schedule{}, results)
+
+			done := make(chan error)
+			go func() { done <- w.run() }()
+
+			time.Sleep(time.Millisecond * 100)
+			started := repo.started.Load()
+
+			cancel()
+			close(repo.release)
+			assert.NoError(t, <-done)
+
+			assert.GreaterOrEqual(t, started, tt.wantMin)
+			assert.LessOrEqual(t, started, tt.wantMax)
+		})
+	}
+}
+
+func TestWorkerOpenLatencyIncludesSchedule(t *testing.T) {
+	repo := &fakeRepo{release: make(chan struct{})}
+	results := make(chan Result, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewWorker(ctx, cancel, repo, testIDs, LoadModeOpen, fixedPacing(t, 100), mixOf(t, "transfer=1"), uniformKeys(t), &schedule{}, results)
+	go w.run()
+
+	// Requests held by the database are measured from when they were
+	// meant to start, not when they were released.
+	time.Sleep(time.Millisecond * 50)
+	close(repo.release)
+
+	assert.GreaterOrEqual(t, (<-results).Latency, time.Millisecond*40)
+}
+
+func TestWorkerRequestUsesMix(t *testing.T) {
+	tests := []struct {
+		mix         string
+		want        models.Operation
+		wantRetries int
+	}{
+		{mix: "read=1", want: models.OperationRead},
+		{mix: "transfer=1", want: models.OperationTransfer, wantRetries: 1},
+		{mix: "insert=1", want: models.OperationInsert},
+		{mix: "scan=1", want: models.OperationScan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mix, func(t *testing.T) {
+			repo := &fakeRepo{}
+			results := make(chan Result, 1)
+			w := NewWorker(context.Background(), func() {}, repo, testIDs, LoadModeClosed, fixedPacing(t, 100), mixOf(t, tt.mix), uniformKeys(t), &schedule{}, results)
+
+			w.request(testIDs, time.Now())
+
+			result := <-results
+			assert.Equal(t, tt.want, repo.made.Load())
+			assert.Equal(t, tt.want, result.Operation)
+			assert.Equal(t, tt.wantRetries, result.Retries)
+			assert.Empty(t, result.Error)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package scaling
+
+import (
+	"fmt"
+	"math"
+)
+
+type Operation string
+
+const (
+	// Adds Factor workers (or removes them if negative).
+	OperationAdd Operation = "add"
+
+	// Multiplies the current workers by Factor.
+	OperationMultiply Operation = "multiply"
+
+	// Divides the current workers by Factor.
+	OperationDivide Operation = "divide"
+
+	// Replaces the current workers with Factor.
+	OperationSet Operation = "set"
+)
+
+// Scaling derives a region's new worker count from its current one.
+// The result is rounded to the nearest worker and clamped between
+// Floor and Ceiling. A Ceiling of zero means there is no upper bound.
+type Scaling struct {
+	Operation Operation `yaml:"operation"`
+	Factor    float64   `yaml:"factor"`
+	Floor     int       `yaml:"floor"`
+	Ceiling   int       `yaml:"ceiling"`
+}
+
+func (s Scaling) Validate() error {
+	switch s.Operation {
+	case OperationAdd, OperationSet:
+	case OperationMultiply, OperationDivide:
+		if s.Factor <= 0 {
+			return fmt.Errorf("factor must be positive for %s", s.Operation)
+		}
+	default:
+		return fmt.Errorf("unsupported operation: %q", s.Operation)
+	}
+
+	if s.Floor < 0 {
+		return fmt.Errorf("floor cannot be negative")
+	}
+
+	if s.Ceiling != 0 && s.Ceiling < s.Floor {
+		return fmt.Errorf("ceiling %d is less than floor %d", s.Ceiling, s.Floor)
+	}
+
+	return nil
+}
+
+func (s Scaling) Apply(current int) (int, error) {
+	if err := s.Validate(); err != nil {
+		return 0, err
+	}
+
+	var next float64
+	switch s.Operation {
+	case OperationAdd:
+		next = float64(current) + s.Factor
+	case OperationMultiply:
+		next = float64(current) * s.Factor
+	case OperationDivide:
+		next = float64(current) / s.Factor
+	case OperationSet:
+		next = s.Factor
+	}
+
+	return s.clamp(int(math.Round(next))), nil
+}
+
+func (s Scaling) clamp(workers int) int {
+	workers = max(workers, s.Floor, 0)
+	if s.Ceiling > 0 {
+		workers = min(workers, s.Ceiling)
+	}
+
+	return workers
+}
@@ -0,0 +1,89 @@
+package scaling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		scaling Scaling
+		current int
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "add",
+			scaling: Scaling{Operation: OperationAdd, Factor: 5},
+			current: 3,
+			want:    8,
+		},
+		{
+			name:    "add negative stops at zero",
+			scaling: Scaling{Operation: OperationAdd, Factor: -5},
+			current: 3,
+			want:    0,
+		},
+		{
+			name:    "multiply",
+			scaling: Scaling{Operation: OperationMultiply, Factor: 2},
+			current: 3,
+			want:    6,
+		},
+		{
+			name:    "multiply from zero respects floor",
+			scaling: Scaling{Operation: OperationMultiply, Factor: 2, Floor: 1},
+			current: 0,
+			want:    1,
+		},
+		{
+			name:    "multiply respects ceiling",
+			scaling: Scaling{Operation: OperationMultiply, Factor: 10, Ceiling: 50},
+			current: 10,
+			want:    50,
+		},
+		{
+			name:    "divide rounds to nearest",
+			scaling: Scaling{Operation: OperationDivide, Factor: 2},
+			current: 5,
+			want:    3,
+		},
+		{
+			name:    "set",
+			scaling: Scaling{Operation: OperationSet, Factor: 7},
+			current: 100,
+			want:    7,
+		},
+		{
+			name:    "divide by zero",
+			scaling: Scaling{Operation: OperationDivide},
+			current: 5,
+			wantErr: true,
+		},
+		{
+			name:    "ceiling below floor",
+			scaling: Scaling{Operation: OperationAdd, Floor: 10, Ceiling: 5},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported operation",
+			scaling: Scaling{Operation: "pow", Factor: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scaling.Apply(tt.current)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package scenario
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/ramp"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scaling"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default.yaml
+var defaultCatalogue []byte
+
+// Definition describes how a scenario changes the workload, how it's
+// presented on the wheel and, if it is time-boxed, how long it lasts
+// before being reverted. Scaling applies to each region's workers,
+// Rate, if provided, to the requests per second made by each worker and
+// Keys, if provided, replaces the distribution of accounts requests are
+// made against. Weight is nil if it wasn't provided, as a weight of
+// zero removes a scenario from the wheel.
+type Definition struct {
+	Name     models.Scenario  `yaml:"name"`
+	Label    string           `yaml:"label"`
+	Colour   string           `yaml:"colour"`
+	Regions  []string         `yaml:"regions"`
+	Scaling  scaling.Scaling  `yaml:",inline"`
+	Rate     *scaling.Scaling `yaml:"rate"`
+	Keys     string           `yaml:"keys"`
+	Ramp     ramp.Profile     `yaml:"ramp"`
+	Duration time.Duration    `yaml:"duration"`
+	Weight   *float64         `yaml:"weight"`
+}
+
+// WheelWeight returns the relative likelihood of the wheel landing on
+// the scenario, which is 1 unless a weight was provided.
+func (d Definition) WheelWeight() float64 {
+	if d.Weight == nil {
+		return 1
+	}
+
+	return *d.Weight
+}
+
+// Catalogue is the set of scenarios available to the wheel.
+type Catalogue struct {
+	Scenarios []Definition `yaml:"scenarios"`
+}
+
+// DefaultCatalogue returns the built-in scenarios.
+func DefaultCatalogue() Catalogue {
+	c, err := ParseCatalogue(defaultCatalogue)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default catalogue: %v", err))
+	}
+
+	return c
+}
+
+// LoadCatalogue reads a YAML (or JSON) catalogue from path, falling back
+// to the default catalogue if path is empty.
+func LoadCatalogue(path string) (Catalogue, error) {
+	if path == "" {
+		return DefaultCatalogue(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Catalogue{}, fmt.Errorf("reading catalogue: %w", err)
+	}
+
+	return ParseCatalogue(data)
+}
+
+// ParseCatalogue parses and validates a YAML (or JSON) catalogue. Any
+// validation errors are reported against the entry they relate to.
+func ParseCatalogue(data []byte) (Catalogue, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var c Catalogue
+	if err := dec.Decode(&c); err != nil {
+		return Catalogue{}, fmt.Errorf("parsing catalogue: %w", err)
+	}
+
+	if err := c.validate(); err != nil {
+		return Catalogue{}, err
+	}
+
+	for i := range c.Scenarios {
+		c.Scenarios[i].setDefaults()
+	}
+
+	return c, nil
+}
+
+// Lookup returns the definition for a given scenario.
+func (c Catalogue) Lookup(s models.Scenario) (Definition, bool) {
+	for _, d := range c.Scenarios {
+		if d.Name == s {
+			return d, true
+		}
+	}
+
+	return Definition{}, false
+}
+
+// WithWeights returns a copy of the catalogue with the wheel weights of
+// the given scenarios overridden. A weight of zero removes a scenario
+// from the wheel without removing it from the catalogue.
+func (c Catalogue) WithWeights(weights map[models.Scenario]float64) (Catalogue, error) {
+	out := Catalogue{Scenarios: slices.Clone(c.Scenarios)}
+
+	for s, w := range weights {
+		i := slices.IndexFunc(out.Scenarios, func(d Definition) bool { return d.Name == s })
+		if i == -1 {
+			return Catalogue{}, fmt.Errorf("unknown scenario: %s", s)
+		}
+
+		if w < 0 {
+			return Catalogue{}, fmt.Errorf("weight for %s cannot be negative", s)
+		}
+
+		out.Scenarios[i].Weight = &w
+	}
+
+	if !out.weighted() {
+		return Catalogue{}, fmt.Errorf("at least one scenario must have a positive weight")
+	}
+
+	return out, nil
+}
+
+// weighted returns true if the wheel can land on any scenario.
+func (c Catalogue) weighted() bool {
+	return slices.ContainsFunc(c.Scenarios, func(d Definition) bool { return d.WheelWeight() > 0 })
+}
+
+func (c Catalogue) validate() error {
+	if len(c.Scenarios) == 0 {
+		return fmt.Errorf("catalogue has no scenarios")
+	}
+
+	var errs []error
+	seen := map[models.Scenario]bool{}
+
+	for i, d := range c.Scenarios {
+		entryErrs := d.validate()
+		if seen[d.Name] {
+			entryErrs = append(entryErrs, fmt.Errorf("duplicate name"))
+		}
+		seen[d.Name] = true
+
+		for _, err := range entryErrs {
+			errs = append(errs, fmt.Errorf("scenario %d (%s): %w", i+1, d.Name, err))
+		}
+	}
+
+	if !c.weighted() {
+		errs = append(errs, fmt.Errorf("at least one scenario must have a positive weight"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d Definition) validate() []error {
+	var errs []error
+
+	if d.Name == "" {
+		errs = append(errs, fmt.Errorf("missing name"))
+	}
+
+	if len(d.Regions) == 0 {
+		errs = append(errs, fmt.Errorf("missing regions"))
+	}
+
+	switch {
+	case d.Scaling.Operation != "":
+		if err := d.Scaling.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	case d.Rate == nil && d.Keys == "":
+		errs = append(errs, fmt.Errorf("missing operation"))
+	}
+
+	if d.Rate != nil {
+		if err := d.Rate.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rate: %w", err))
+		}
+	}
+
+	if d.Keys != "" {
+		if _, err := models.ParseKeyDistribution(d.Keys); err != nil {
+			errs = append(errs, fmt.Errorf("keys: %w", err))
+		}
+	}
+
+	if err := d.Ramp.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if d.Duration < 0 {
+		errs = append(errs, fmt.Errorf("duration cannot be negative"))
+	}
+
+	if d.Duration == 0 && (d.Ramp.Shape == ramp.ShapeSine || d.Ramp.Shape == ramp.ShapeSpike) {
+		errs = append(errs, fmt.Errorf("%s ramp requires a duration", d.Ramp.Shape))
+	}
+
+	if d.WheelWeight() < 0 {
+		errs = append(errs, fmt.Errorf("weight cannot be negative"))
+	}
+
+	if d.Colour != "" {
+		if _, err := parseColour(d.Colour); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func (d *Definition) setDefaults() {
+	if d.Label == "" {
+		d.Label = string(d.Name)
+	}
+
+	if d.Ramp.Shape == "" {
+		d.Ramp.Shape = ramp.ShapeInstant
+	}
+
+	// Workers can't make requests at a rate of zero.
+	if d.Rate != nil && d.Rate.Floor == 0 {
+		d.Rate.Floor = 1
+	}
+}
+
+// target returns the settings a region should end up with, given its
+// current settings.
+func (d Definition) target(current Settings) (Settings, error) {
+	next := current
+
+	if d.Scaling.Operation != "" {
+		workers, err := d.Scaling.Apply(current.Workers)
+		if err != nil {
+			return Settings{}, fmt.Errorf("scaling workers: %w", err)
+		}
+		next.Workers = workers
+	}
+
+	if d.Rate != nil {
+		rate, err := d.Rate.Apply(current.Rate)
+		if err != nil {
+			return Settings{}, fmt.Errorf("scaling rate: %w", err)
+		}
+		next.Rate = rate
+	}
+
+	if d.Keys != "" {
+		next.Keys = d.Keys
+	}
+
+	return next, nil
+}
+
+// Color returns the definition's colour and whether one was provided.
+func (d Definition) Color() (color.RGBA, bool) {
+	if d.Colour == "" {
+		return color.RGBA{}, false
+	}
+
+	c, err := parseColour(d.Colour)
+	return c, err == nil
+}
+
+func parseColour(s string) (color.RGBA, error) {
+	c := color.RGBA{A: 255}
+	if len(s) != 7 {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q, expected #rrggbb", s)
+	}
+
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q, expected #rrggbb", s)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,207 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/ramp"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scaling"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCatalogue(t *testing.T) {
+	c := DefaultCatalogue()
+	assert.Len(t, c.Scenarios, 10)
+
+	d, ok := c.Lookup(models.ScenarioFlashSale)
+	assert.True(t, ok)
+	assert.Equal(t, scaling.OperationMultiply, d.Scaling.Operation)
+	assert.Equal(t, 10.0, d.Scaling.Factor)
+	assert.Equal(t, time.Minute*10, d.Duration)
+
+	// Holds at 10x for the whole window, as described.
+	assert.Equal(t, ramp.ShapeInstant, d.Ramp.Shape)
+	assert.Equal(t, 1.0, d.Ramp.Level(time.Minute*9))
+}
+
+func TestParseCatalogue(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr []string
+	}{
+		{
+			name: "valid yaml",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    operation: add
+    factor: 1`,
+		},
+		{
+			name: "rate only",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    rate:
+      operation: multiply
+      factor: 2`,
+		},
+		{
+			name: "keys only",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    keys: zipf=1.2`,
+		},
+		{
+			name: "valid json",
+			data: `{"scenarios": [{"name": "a", "regions": ["r1"], "operation": "set", "factor": 3, "duration": "5m"}]}`,
+		},
+		{
+			name: "errors reported per entry",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    operation: add
+  - name: b
+    operation: pow
+  - name: c
+    regions: [r1]
+  - name: a
+    regions: [r1]
+    operation: divide
+    colour: red
+  - name: d
+    regions: [r1]
+    keys: hotspot`,
+			wantErr: []string{
+				"scenario 2 (b): missing regions",
+				`scenario 2 (b): unsupported operation: "pow"`,
+				"scenario 3 (c): missing operation",
+				"scenario 4 (a): factor must be positive for divide",
+				`scenario 4 (a): invalid colour "red", expected #rrggbb`,
+				"duplicate name",
+				`scenario 5 (d): keys: unsupported key distribution: "hotspot"`,
+			},
+		},
+		{
+			name: "no positive weights",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    operation: add
+    factor: 1
+    weight: 0`,
+			wantErr: []string{"at least one scenario must have a positive weight"},
+		},
+		{
+			name: "negative weight",
+			data: `
+scenarios:
+  - name: a
+    regions: [r1]
+    operation: add
+    factor: 1
+    weight: -1`,
+			wantErr: []string{"scenario 1 (a): weight cannot be negative"},
+		},
+		{
+			name:    "unknown field",
+			data:    `{"scenarios": [{"name": "a", "regoins": ["r1"]}]}`,
+			wantErr: []string{"field regoins not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCatalogue([]byte(tt.data))
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestWithWeights(t *testing.T) {
+	c := DefaultCatalogue()
+
+	weighted, err := c.WithWeights(map[models.Scenario]float64{
+		models.ScenarioFlashSale: 3,
+		models.ScenarioTest:      0,
+	})
+	assert.NoError(t, err)
+
+	d, _ := weighted.Lookup(models.ScenarioFlashSale)
+	assert.Equal(t, 3.0, d.WheelWeight())
+
+	d, _ = weighted.Lookup(models.ScenarioTest)
+	assert.Equal(t, 0.0, d.WheelWeight())
+
+	d, _ = c.Lookup(models.ScenarioFlashSale)
+	assert.Equal(t, 1.0, d.WheelWeight(), "original catalogue should be unchanged")
+
+	_, err = c.WithWeights(map[models.Scenario]float64{"nope": 1})
+	assert.ErrorContains(t, err, "unknown scenario: nope")
+}
+
+func TestWheelWeight(t *testing.T) {
+	c, err := ParseCatalogue([]byte(`
+scenarios:
+  - name: unset
+    regions: [r1]
+    operation: add
+    factor: 1
+  - name: removed
+    regions: [r1]
+    operation: add
+    factor: 1
+    weight: 0
+  - name: heavy
+    regions: [r1]
+    operation: add
+    factor: 1
+    weight: 2.5`))
+	assert.NoError(t, err)
+
+	for name, want := range map[models.Scenario]float64{"unset": 1, "removed": 0, "heavy": 2.5} {
+		d, ok := c.Lookup(name)
+		assert.True(t, ok)
+		assert.Equal(t, want, d.WheelWeight(), name)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	d := Definition{
+		Scaling: scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+	}
+
+	got, err := d.target(Settings{Workers: 3, Rate: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Workers: 6, Rate: 100}, got)
+
+	d = Definition{
+		Rate: &scaling.Scaling{Operation: scaling.OperationDivide, Factor: 4, Floor: 1},
+	}
+
+	got, err = d.target(Settings{Workers: 3, Rate: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Workers: 3, Rate: 1}, got)
+
+	d = Definition{Keys: "hotset=1:90"}
+
+	got, err = d.target(Settings{Workers: 3, Rate: 100, Keys: "uniform"})
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Workers: 3, Rate: 100, Keys: "hotset=1:90"}, got)
+}
@@ -0,0 +1,418 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/ramp"
+	"github.com/codingconcepts/scale-spin/apps/pkg/repo"
+)
+
+// revertActor is recorded as the actor for scenarios reverted by the
+// controller once their window expires.
+const revertActor = "controller"
+
+// Controller applies scenarios to the workload table, ramps workers
+// towards their target and reverts time-boxed scenarios once their
+// window has expired.
+type Controller struct {
+	store     store
+	catalogue Catalogue
+	retry     repo.RetryPolicy
+}
+
+func NewController(db *sql.DB, catalogue Catalogue) *Controller {
+	return &Controller{
+		store:     postgresStore{db: db},
+		catalogue: catalogue,
+		retry:     repo.DefaultRetryPolicy,
+	}
+}
+
+// Apply scales the workers (and request rate) for each of the scenario's
+// regions on behalf of actor, recording the outcome in the scenario
+// history. If the scenario is time-boxed or ramps over time, the
+// previous, target and applied settings are persisted alongside its
+// window, so they can be ramped between and undone when it expires.
+func (c *Controller) Apply(ctx context.Context, s models.Scenario, actor string) error {
+	err := c.apply(ctx, s, actor)
+	if err == nil {
+		return nil
+	}
+
+	def, _ := c.catalogue.Lookup(s)
+	entry := HistoryEntry{
+		Scenario: s,
+		Regions:  append([]string{}, def.Regions...),
+		Actor:    actor,
+		Outcome:  OutcomeFailed,
+		Error:    err.Error(),
+	}
+
+	if herr := c.store.insertHistory(ctx, entry); herr != nil {
+		log.Printf("error recording failed scenario: %v", herr)
+	}
+
+	return err
+}
+
+func (c *Controller) apply(ctx context.Context, s models.Scenario, actor string) error {
+	def, ok := c.catalogue.Lookup(s)
+	if !ok {
+		return fmt.Errorf("unsupported scenario: %s", s)
+	}
+
+	return c.inTx(ctx, func(tx storeTx) error {
+		return applyTx(ctx, tx, def, actor)
+	})
+}
+
+func applyTx(ctx context.Context, tx storeTx, def Definition, actor string) error {
+	previous, err := tx.fetchSettings(ctx, def.Regions)
+	if err != nil {
+		return fmt.Errorf("fetching previous settings: %w", err)
+	}
+
+	target := map[string]Settings{}
+	applied := map[string]Settings{}
+	for region, settings := range previous {
+		next, err := def.target(settings)
+		if err != nil {
+			return fmt.Errorf("scaling %s: %w", region, err)
+		}
+		target[region] = next
+
+		current := between(settings, next, def.Ramp.Level(0))
+		applied[region] = current
+		if err = tx.updateSettings(ctx, region, current); err != nil {
+			return fmt.Errorf("updating settings for %s: %w", region, err)
+		}
+
+		log.Printf("scaling %s: %s -> %s", region, settings, next)
+	}
+
+	if def.Duration > 0 || !def.Ramp.Settled(0) {
+		if err = insertWindow(ctx, tx, def, previous, target, applied); err != nil {
+			return fmt.Errorf("inserting window: %w", err)
+		}
+	}
+
+	entry := HistoryEntry{
+		Scenario: def.Name,
+		Regions:  def.Regions,
+		Before:   previous,
+		After:    target,
+		Actor:    actor,
+		Outcome:  OutcomeApplied,
+	}
+
+	if err = tx.insertHistory(ctx, entry); err != nil {
+		return fmt.Errorf("recording history: %w", err)
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds. Workloads
+// and windows are locked for update, so transactions that contend with
+// another controller are retried.
+func (c *Controller) inTx(ctx context.Context, fn func(tx storeTx) error) error {
+	retries, err := c.retry.Retry(ctx, func() error {
+		tx, err := c.store.begin(ctx)
+		if err != nil {
+			return fmt.Errorf("beginning transaction: %w", err)
+		}
+		defer tx.rollback()
+
+		if err = fn(tx); err != nil {
+			return err
+		}
+
+		if err = tx.commit(); err != nil {
+			return fmt.Errorf("committing transaction: %w", err)
+		}
+
+		return nil
+	})
+
+	if retries > 0 {
+		log.Printf("transaction retried %d times", retries)
+	}
+
+	return err
+}
+
+// Watch advances ramping scenarios and reverts expired ones every
+// interval until the context is cancelled. Windows are persisted, so
+// any that expired while nothing was watching are reverted on the
+// first tick.
+func (c *Controller) Watch(ctx context.Context, interval time.Duration) {
+	ticks := time.NewTicker(interval)
+	defer ticks.Stop()
+
+	for {
+		if _, err := c.Tick(ctx); err != nil {
+			log.Printf("error updating scenario windows: %v", err)
+		}
+
+		select {
+		case <-ticks.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Settle advances ramping scenarios and reverts expired ones every
+// interval until no windows are active or the context is cancelled.
+func (c *Controller) Settle(ctx context.Context, interval time.Duration) error {
+	ticks := time.NewTicker(interval)
+	defer ticks.Stop()
+
+	for {
+		active, err := c.Tick(ctx)
+		if err != nil {
+			return err
+		}
+
+		if active == 0 {
+			return nil
+		}
+
+		select {
+		case <-ticks.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// window is a time-boxed or ramping scenario. revert holds each region's
+// settings from before the scenario was applied, target the settings it
+// ramps towards and applied the settings it last moved the region to.
+// Windows only ever move a region by the change between their own
+// settings, so changes made by anything else while they're active are
+// kept when they ramp and when they're reverted.
+type window struct {
+	id        string
+	scenario  string
+	elapsed   time.Duration
+	hasExpiry bool
+	expired   bool
+	profile   ramp.Profile
+	revert    map[string]Settings
+	target    map[string]Settings
+	applied   map[string]Settings
+}
+
+// desired returns the settings the window wants a region to be at.
+func (w window) desired(region string) Settings {
+	return between(w.revert[region], w.target[region], w.profile.Level(w.elapsed))
+}
+
+// Tick advances ramping scenarios and reverts expired ones once,
+// returning the number of windows still active.
+func (c *Controller) Tick(ctx context.Context) (int, error) {
+	var active int
+	err := c.inTx(ctx, func(tx storeTx) error {
+		var err error
+		active, err = c.tick(ctx, tx)
+		return err
+	})
+
+	return active, err
+}
+
+func (c *Controller) tick(ctx context.Context, tx storeTx) (int, error) {
+	windows, err := fetchActiveWindows(ctx, tx)
+	if err != nil {
+		return 0, fmt.Errorf("fetching active windows: %w", err)
+	}
+
+	active := len(windows)
+
+	// Revert expired windows newest first, so that overlapping windows
+	// leave each region as it was before the oldest of them.
+	for i := len(windows) - 1; i >= 0; i-- {
+		w := windows[i]
+		if !w.expired {
+			continue
+		}
+
+		regions := slices.Sorted(maps.Keys(w.revert))
+		current, err := tx.fetchSettings(ctx, regions)
+		if err != nil {
+			return 0, fmt.Errorf("fetching current settings: %w", err)
+		}
+
+		reverted := map[string]Settings{}
+		for region, settings := range current {
+			reverted[region] = shift(settings, w.applied[region], w.revert[region])
+			if err = tx.updateSettings(ctx, region, reverted[region]); err != nil {
+				return 0, fmt.Errorf("reverting settings for %s: %w", region, err)
+			}
+		}
+
+		if err = tx.endWindow(ctx, w.id); err != nil {
+			return 0, fmt.Errorf("ending window: %w", err)
+		}
+		active--
+
+		entry := HistoryEntry{
+			Scenario: models.Scenario(w.scenario),
+			Regions:  regions,
+			Before:   current,
+			After:    reverted,
+			Actor:    revertActor,
+			Outcome:  OutcomeReverted,
+		}
+
+		if err = tx.insertHistory(ctx, entry); err != nil {
+			return 0, fmt.Errorf("recording history: %w", err)
+		}
+
+		log.Printf("reverted scenario: %s", w.scenario)
+	}
+
+	for _, w := range windows {
+		if w.expired {
+			continue
+		}
+
+		if err = c.ramp(ctx, tx, w); err != nil {
+			return 0, fmt.Errorf("ramping %s: %w", w.scenario, err)
+		}
+
+		if !w.hasExpiry && w.profile.Settled(w.elapsed) {
+			if err = tx.endWindow(ctx, w.id); err != nil {
+				return 0, fmt.Errorf("ending window: %w", err)
+			}
+			active--
+
+			log.Printf("settled scenario: %s", w.scenario)
+		}
+	}
+
+	return active, nil
+}
+
+// ramp moves each of a window's regions along its ramp, by the change
+// since the window last moved them.
+func (c *Controller) ramp(ctx context.Context, tx storeTx, w window) error {
+	moved := false
+	for region := range w.target {
+		if w.desired(region) != w.applied[region] {
+			moved = true
+		}
+	}
+
+	if !moved {
+		return nil
+	}
+
+	current, err := tx.fetchSettings(ctx, slices.Sorted(maps.Keys(w.target)))
+	if err != nil {
+		return fmt.Errorf("fetching current settings: %w", err)
+	}
+
+	applied := map[string]Settings{}
+	for region, settings := range current {
+		applied[region] = w.desired(region)
+		if err = tx.updateSettings(ctx, region, shift(settings, w.applied[region], applied[region])); err != nil {
+			return fmt.Errorf("updating settings for %s: %w", region, err)
+		}
+	}
+
+	if err = updateApplied(ctx, tx, w.id, applied); err != nil {
+		return fmt.Errorf("updating window: %w", err)
+	}
+
+	return nil
+}
+
+func insertWindow(ctx context.Context, tx storeTx, def Definition, revert, target, applied map[string]Settings) error {
+	profileJSON, err := json.Marshal(def.Ramp)
+	if err != nil {
+		return fmt.Errorf("marshalling profile: %w", err)
+	}
+
+	revertJSON, err := json.Marshal(revert)
+	if err != nil {
+		return fmt.Errorf("marshalling revert: %w", err)
+	}
+
+	targetJSON, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("marshalling target: %w", err)
+	}
+
+	appliedJSON, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("marshalling applied: %w", err)
+	}
+
+	row := windowRow{
+		scenario: string(def.Name),
+		duration: def.Duration,
+		profile:  profileJSON,
+		revert:   revertJSON,
+		target:   targetJSON,
+		applied:  appliedJSON,
+	}
+
+	return tx.insertWindow(ctx, row)
+}
+
+func updateApplied(ctx context.Context, tx storeTx, id string, applied map[string]Settings) error {
+	appliedJSON, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("marshalling applied: %w", err)
+	}
+
+	return tx.updateApplied(ctx, id, appliedJSON)
+}
+
+func fetchActiveWindows(ctx context.Context, tx storeTx) ([]window, error) {
+	rows, err := tx.fetchWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]window, len(rows))
+	for i, row := range rows {
+		w := window{
+			id:        row.id,
+			scenario:  row.scenario,
+			elapsed:   row.elapsed,
+			hasExpiry: row.hasExpiry,
+			expired:   row.expired,
+		}
+
+		if err = json.Unmarshal(row.profile, &w.profile); err != nil {
+			return nil, fmt.Errorf("parsing profile: %w", err)
+		}
+
+		if err = json.Unmarshal(row.revert, &w.revert); err != nil {
+			return nil, fmt.Errorf("parsing revert: %w", err)
+		}
+
+		if err = json.Unmarshal(row.target, &w.target); err != nil {
+			return nil, fmt.Errorf("parsing target: %w", err)
+		}
+
+		if err = json.Unmarshal(row.applied, &w.applied); err != nil {
+			return nil, fmt.Errorf("parsing applied: %w", err)
+		}
+
+		windows[i] = w
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,516 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/ramp"
+	"github.com/codingconcepts/scale-spin/apps/pkg/repo"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scaling"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore keeps workloads, windows and history in memory. Changes
+// made in a transaction are only kept once it's committed, and windows
+// are timed using now rather than a clock.
+type fakeStore struct {
+	now       time.Time
+	workloads map[string]Settings
+	windows   []fakeWindow
+	history   []HistoryEntry
+
+	// Errors returned by the next commits, in order.
+	commitErrs []error
+}
+
+type fakeWindow struct {
+	row       windowRow
+	startedAt time.Time
+	expiresAt time.Time
+	ended     bool
+}
+
+func newFakeStore(workloads map[string]Settings) *fakeStore {
+	return &fakeStore{
+		now:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		workloads: workloads,
+	}
+}
+
+func (s *fakeStore) begin(ctx context.Context) (storeTx, error) {
+	return &fakeTx{
+		store:     s,
+		workloads: maps.Clone(s.workloads),
+		windows:   slices.Clone(s.windows),
+		history:   slices.Clone(s.history),
+	}, nil
+}
+
+func (s *fakeStore) insertHistory(ctx context.Context, e HistoryEntry) error {
+	s.history = append(s.history, e)
+	return nil
+}
+
+// outcomes returns the scenario and outcome of each history entry.
+func (s *fakeStore) outcomes() []string {
+	var outcomes []string
+	for _, e := range s.history {
+		outcomes = append(outcomes, string(e.Scenario)+" "+string(e.Outcome))
+	}
+
+	return outcomes
+}
+
+type fakeTx struct {
+	store     *fakeStore
+	workloads map[string]Settings
+	windows   []fakeWindow
+	history   []HistoryEntry
+}
+
+func (t *fakeTx) fetchSettings(ctx context.Context, regions []string) (map[string]Settings, error) {
+	settings := map[string]Settings{}
+	for _, region := range regions {
+		if s, ok := t.workloads[region]; ok {
+			settings[region] = s
+		}
+	}
+
+	return settings, nil
+}
+
+func (t *fakeTx) updateSettings(ctx context.Context, region string, s Settings) error {
+	if s.Keys == "" {
+		s.Keys = t.workloads[region].Keys
+	}
+	t.workloads[region] = s
+
+	return nil
+}
+
+func (t *fakeTx) fetchWindows(ctx context.Context) ([]windowRow, error) {
+	now := t.store.now
+
+	var rows []windowRow
+	for _, w := range t.windows {
+		if w.ended {
+			continue
+		}
+
+		row := w.row
+		row.elapsed = now.Sub(w.startedAt)
+		row.hasExpiry = !w.expiresAt.IsZero()
+		row.expired = row.hasExpiry && !now.Before(w.expiresAt)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func (t *fakeTx) insertWindow(ctx context.Context, row windowRow) error {
+	w := fakeWindow{row: row, startedAt: t.store.now}
+	w.row.id = strconv.Itoa(len(t.windows) + 1)
+	if row.duration > 0 {
+		w.expiresAt = t.store.now.Add(row.duration)
+	}
+
+	t.windows = append(t.windows, w)
+	return nil
+}
+
+func (t *fakeTx) updateApplied(ctx context.Context, id string, applied []byte) error {
+	return t.updateWindow(id, func(w *fakeWindow) { w.row.applied = applied })
+}
+
+func (t *fakeTx) endWindow(ctx context.Context, id string) error {
+	return t.updateWindow(id, func(w *fakeWindow) { w.ended = true })
+}
+
+func (t *fakeTx) updateWindow(id string, fn func(w *fakeWindow)) error {
+	i := slices.IndexFunc(t.windows, func(w fakeWindow) bool { return w.row.id == id })
+	if i == -1 {
+		return errors.New("window not found")
+	}
+
+	fn(&t.windows[i])
+	return nil
+}
+
+func (t *fakeTx) insertHistory(ctx context.Context, e HistoryEntry) error {
+	t.history = append(t.history, e)
+	return nil
+}
+
+func (t *fakeTx) commit() error {
+	if len(t.store.commitErrs) > 0 {
+		err := t.store.commitErrs[0]
+		t.store.commitErrs = t.store.commitErrs[1:]
+		return err
+	}
+
+	t.store.workloads = t.workloads
+	t.store.windows = t.windows
+	t.store.history = t.history
+
+	return nil
+}
+
+func (t *fakeTx) rollback() error {
+	return nil
+}
+
+func newTestController(store store, defs ...Definition) *Controller {
+	return &Controller{
+		store:     store,
+		catalogue: Catalogue{Scenarios: defs},
+		retry:     repo.RetryPolicy{MaxRetries: 2, BaseDelay: time.Microsecond, MaxDelay: time.Microsecond},
+	}
+}
+
+// tick advances the store's clock by d and ticks the controller,
+// returning the number of windows still active.
+func tick(t *testing.T, c *Controller, s *fakeStore, d time.Duration) int {
+	t.Helper()
+
+	s.now = s.now.Add(d)
+
+	active, err := c.Tick(context.Background())
+	assert.NoError(t, err)
+
+	return active
+}
+
+func TestApplyPermanent(t *testing.T) {
+	double := Definition{
+		Name:    "double",
+		Regions: []string{"r1", "r2"},
+		Scaling: scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+	}
+
+	s := newFakeStore(map[string]Settings{
+		"r1": {Workers: 3, Rate: 100},
+		"r2": {Workers: 1, Rate: 50},
+		"r3": {Workers: 5, Rate: 100},
+	})
+	c := newTestController(s, double)
+
+	assert.NoError(t, c.Apply(context.Background(), "double", "test"))
+	assert.Equal(t, map[string]Settings{
+		"r1": {Workers: 6, Rate: 100},
+		"r2": {Workers: 2, Rate: 50},
+		"r3": {Workers: 5, Rate: 100},
+	}, s.workloads)
+
+	assert.Empty(t, s.windows)
+	assert.Equal(t, 0, tick(t, c, s, time.Hour))
+
+	assert.Equal(t, []HistoryEntry{{
+		Scenario: "double",
+		Regions:  []string{"r1", "r2"},
+		Before:   map[string]Settings{"r1": {Workers: 3, Rate: 100}, "r2": {Workers: 1, Rate: 50}},
+		After:    map[string]Settings{"r1": {Workers: 6, Rate: 100}, "r2": {Workers: 2, Rate: 50}},
+		Actor:    "test",
+		Outcome:  OutcomeApplied,
+	}}, s.history)
+}
+
+func TestApplyFailure(t *testing.T) {
+	double := Definition{
+		Name:     "double",
+		Regions:  []string{"r1"},
+		Scaling:  scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+		Duration: time.Minute,
+	}
+
+	tests := []struct {
+		name       string
+		scenario   models.Scenario
+		commitErrs []error
+		wantErr    string
+	}{
+		{
+			name:     "unsupported scenario",
+			scenario: "missing",
+			wantErr:  "unsupported scenario: missing",
+		},
+		{
+			name:       "commit fails",
+			scenario:   "double",
+			commitErrs: []error{errors.New("connection reset")},
+			wantErr:    "committing transaction: connection reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeStore(map[string]Settings{"r1": {Workers: 3, Rate: 100}})
+			s.commitErrs = tt.commitErrs
+			c := newTestController(s, double)
+
+			err := c.Apply(context.Background(), tt.scenario, "test")
+			assert.EqualError(t, err, tt.wantErr)
+
+			// Nothing but the failure is recorded.
+			assert.Equal(t, map[string]Settings{"r1": {Workers: 3, Rate: 100}}, s.workloads)
+			assert.Empty(t, s.windows)
+			assert.Equal(t, []string{string(tt.scenario) + " failed"}, s.outcomes())
+			assert.Equal(t, tt.wantErr, s.history[0].Error)
+		})
+	}
+}
+
+func TestRevertExpiredWindow(t *testing.T) {
+	double := Definition{
+		Name:     "double",
+		Regions:  []string{"r1"},
+		Scaling:  scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+		Duration: time.Minute,
+	}
+
+	tests := []struct {
+		name    string
+		restart bool
+		ticks   []time.Duration
+	}{
+		{
+			name:  "watched until it expires",
+			ticks: []time.Duration{time.Second * 30, time.Second * 30},
+		},
+		{
+			name:  "expired while not watched",
+			ticks: []time.Duration{time.Hour},
+		},
+		{
+			name:    "expired across a restart",
+			restart: true,
+			ticks:   []time.Duration{time.Second * 30, time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeStore(map[string]Settings{"r1": {Workers: 3, Rate: 100}})
+			c := newTestController(s, double)
+
+			assert.NoError(t, c.Apply(context.Background(), "double", "test"))
+			assert.Equal(t, Settings{Workers: 6, Rate: 100}, s.workloads["r1"])
+
+			for i, d := range tt.ticks {
+				// Windows are only known to the store, so a new
+				// controller picks up where the last one left off.
+				if tt.restart && i > 0 {
+					c = newTestController(s, double)
+				}
+
+				active := tick(t, c, s, d)
+				if i < len(tt.ticks)-1 {
+					assert.Equal(t, 1, active)
+					assert.Equal(t, Settings{Workers: 6, Rate: 100}, s.workloads["r1"])
+				} else {
+					assert.Equal(t, 0, active)
+				}
+			}
+
+			assert.Equal(t, Settings{Workers: 3, Rate: 100}, s.workloads["r1"])
+			assert.Equal(t, []string{"double applied", "double reverted"}, s.outcomes())
+
+			// Reverted windows stay reverted.
+			assert.Equal(t, 0, tick(t, c, s, time.Hour))
+			assert.Equal(t, Settings{Workers: 3, Rate: 100}, s.workloads["r1"])
+			assert.Len(t, s.history, 2)
+		})
+	}
+}
+
+func TestRetryContention(t *testing.T) {
+	double := Definition{
+		Name:     "double",
+		Regions:  []string{"r1"},
+		Scaling:  scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+		Duration: time.Minute,
+	}
+
+	contention := &pgconn.PgError{Code: "40001"}
+
+	s := newFakeStore(map[string]Settings{"r1": {Workers: 3, Rate: 100}})
+	c := newTestController(s, double)
+
+	s.commitErrs = []error{contention, contention}
+	assert.NoError(t, c.Apply(context.Background(), "double", "test"))
+	assert.Equal(t, Settings{Workers: 6, Rate: 100}, s.workloads["r1"])
+	assert.Len(t, s.windows, 1)
+
+	s.commitErrs = []error{contention}
+	assert.Equal(t, 0, tick(t, c, s, time.Minute))
+	assert.Equal(t, Settings{Workers: 3, Rate: 100}, s.workloads["r1"])
+	assert.Equal(t, []string{"double applied", "double reverted"}, s.outcomes())
+
+	// Gives up once the policy's retries are used up.
+	s.commitErrs = []error{contention, contention, contention}
+	err := c.Apply(context.Background(), "double", "test")
+	assert.ErrorIs(t, err, contention)
+	assert.Equal(t, Settings{Workers: 3, Rate: 100}, s.workloads["r1"])
+	assert.Equal(t, []string{"double applied", "double reverted", "double failed"}, s.outcomes())
+}
+
+func TestRevertKeepsPermanentChanges(t *testing.T) {
+	double := Definition{
+		Name:     "double",
+		Regions:  []string{"r1"},
+		Scaling:  scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+		Duration: time.Minute,
+	}
+	addTwo := Definition{
+		Name:    "add-two",
+		Regions: []string{"r1"},
+		Scaling: scaling.Scaling{Operation: scaling.OperationAdd, Factor: 2},
+	}
+
+	s := newFakeStore(map[string]Settings{"r1": {Workers: 3, Rate: 100}})
+	c := newTestController(s, double, addTwo)
+
+	assert.NoError(t, c.Apply(context.Background(), "double", "test"))
+	assert.Equal(t, Settings{Workers: 6, Rate: 100}, s.workloads["r1"])
+
+	// A permanent scenario applied while the window is open.
+	assert.NoError(t, c.Apply(context.Background(), "add-two", "test"))
+	assert.Equal(t, Settings{Workers: 8, Rate: 100}, s.workloads["r1"])
+
+	assert.Equal(t, 1, tick(t, c, s, time.Second*30))
+	assert.Equal(t, Settings{Workers: 8, Rate: 100}, s.workloads["r1"])
+
+	assert.Equal(t, 0, tick(t, c, s, time.Second*30))
+	assert.Equal(t, Settings{Workers: 5, Rate: 100}, s.workloads["r1"])
+
+	assert.Equal(t, []string{"double applied", "add-two applied", "double reverted"}, s.outcomes())
+	assert.Equal(t, map[string]Settings{"r1": {Workers: 8, Rate: 100}}, s.history[2].Before)
+	assert.Equal(t, map[string]Settings{"r1": {Workers: 5, Rate: 100}}, s.history[2].After)
+	assert.Equal(t, revertActor, s.history[2].Actor)
+}
+
+func TestRevertOverlappingWindows(t *testing.T) {
+	initial := Settings{Workers: 3, Rate: 100, Keys: "uniform"}
+
+	tests := []struct {
+		name       string
+		double     time.Duration
+		newProduct time.Duration
+		wantFirst  Settings
+	}{
+		{
+			name:       "oldest expires first",
+			double:     time.Minute,
+			newProduct: time.Minute * 2,
+			wantFirst:  Settings{Workers: 7, Rate: 200, Keys: "hotset=1:90"},
+		},
+		{
+			name:       "newest expires first",
+			double:     time.Minute * 2,
+			newProduct: time.Minute,
+			wantFirst:  Settings{Workers: 6, Rate: 100, Keys: "uniform"},
+		},
+		{
+			name:       "both expire together",
+			double:     time.Minute,
+			newProduct: time.Minute,
+			wantFirst:  initial,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			double := Definition{
+				Name:     "double",
+				Regions:  []string{"r1"},
+				Scaling:  scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2},
+				Duration: tt.double,
+			}
+			newProduct := Definition{
+				Name:     "new-product",
+				Regions:  []string{"r1"},
+				Scaling:  scaling.Scaling{Operation: scaling.OperationAdd, Factor: 4},
+				Rate:     &scaling.Scaling{Operation: scaling.OperationMultiply, Factor: 2, Floor: 1},
+				Keys:     "hotset=1:90",
+				Duration: tt.newProduct,
+			}
+
+			s := newFakeStore(map[string]Settings{"r1": initial})
+			c := newTestController(s, double, newProduct)
+
+			assert.NoError(t, c.Apply(context.Background(), "double", "test"))
+			assert.NoError(t, c.Apply(context.Background(), "new-product", "test"))
+			assert.Equal(t, Settings{Workers: 10, Rate: 200, Keys: "hotset=1:90"}, s.workloads["r1"])
+
+			tick(t, c, s, time.Minute)
+			assert.Equal(t, tt.wantFirst, s.workloads["r1"])
+
+			// Whichever expires first, the region ends up as it was
+			// before both.
+			assert.Equal(t, 0, tick(t, c, s, time.Minute))
+			assert.Equal(t, initial, s.workloads["r1"])
+		})
+	}
+}
+
+func TestRampKeepsOtherChanges(t *testing.T) {
+	def := Definition{
+		Name:    "thirteen",
+		Regions: []string{"r1"},
+		Scaling: scaling.Scaling{Operation: scaling.OperationSet, Factor: 13},
+		Ramp:    ramp.Profile{Shape: ramp.ShapeLinear, Ramp: time.Minute},
+	}
+
+	s := newFakeStore(map[string]Settings{"r1": {Workers: 3, Rate: 100}})
+	c := newTestController(s, def)
+
+	assert.NoError(t, c.Apply(context.Background(), "thirteen", "test"))
+	assert.Equal(t, Settings{Workers: 3, Rate: 100}, s.workloads["r1"])
+
+	// Something else adds a worker before the ramp moves.
+	s.workloads["r1"] = Settings{Workers: 4, Rate: 100}
+
+	assert.Equal(t, 1, tick(t, c, s, time.Second*30))
+	assert.Equal(t, Settings{Workers: 9, Rate: 100}, s.workloads["r1"])
+
+	assert.Equal(t, 0, tick(t, c, s, time.Second*30))
+	assert.Equal(t, Settings{Workers: 14, Rate: 100}, s.workloads["r1"])
+}
+
+func TestShiftClamps(t *testing.T) {
+	got := shift(Settings{Workers: 1, Rate: 5}, Settings{Workers: 10, Rate: 50}, Settings{Workers: 2, Rate: 10})
+	assert.Equal(t, Settings{Workers: 0, Rate: 1}, got)
+}
+
+func TestLinearRampMovesWorkers(t *testing.T) {
+	catalogue := DefaultCatalogue()
+	def, ok := catalogue.Lookup(models.ScenarioScaleUpEU)
+	assert.True(t, ok)
+	assert.Equal(t, ramp.ShapeLinear, def.Ramp.Shape)
+
+	s := newFakeStore(map[string]Settings{def.Regions[0]: {Workers: 4, Rate: 100}})
+	c := newTestController(s, catalogue.Scenarios...)
+
+	// Applying the scenario leaves workers where they are...
+	assert.NoError(t, c.Apply(context.Background(), models.ScenarioScaleUpEU, "test"))
+	assert.Equal(t, Settings{Workers: 4, Rate: 100}, s.workloads[def.Regions[0]])
+
+	// ...until they're moved along the ramp by later ticks.
+	assert.Equal(t, 1, tick(t, c, s, time.Second*15))
+	assert.Equal(t, Settings{Workers: 5, Rate: 100}, s.workloads[def.Regions[0]])
+
+	assert.Equal(t, 1, tick(t, c, s, time.Second*15))
+	assert.Equal(t, Settings{Workers: 6, Rate: 100}, s.workloads[def.Regions[0]])
+
+	// The window ends once the ramp has settled.
+	assert.Equal(t, 0, tick(t, c, s, time.Second*30))
+	assert.Equal(t, Settings{Workers: 8, Rate: 100}, s.workloads[def.Regions[0]])
+	assert.Equal(t, 0, tick(t, c, s, time.Minute))
+	assert.Equal(t, Settings{Workers: 8, Rate: 100}, s.workloads[def.Regions[0]])
+}
@@ -0,0 +1,139 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Outcome string
+
+const (
+	OutcomeApplied  Outcome = "applied"
+	OutcomeFailed   Outcome = "failed"
+	OutcomeReverted Outcome = "reverted"
+)
+
+// HistoryEntry is a record of a scenario being applied to (or reverted
+// from) the workload table.
+type HistoryEntry struct {
+	ID        string              `json:"id"`
+	Scenario  models.Scenario     `json:"scenario"`
+	Regions   []string            `json:"regions"`
+	Before    map[string]Settings `json:"before"`
+	After     map[string]Settings `json:"after"`
+	Actor     string              `json:"actor"`
+	Outcome   Outcome             `json:"outcome"`
+	Error     string              `json:"error,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// HistoryFilter narrows down the entries returned by History. Zero
+// values are ignored.
+type HistoryFilter struct {
+	Scenario models.Scenario
+	Since    time.Time
+	Limit    int
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// History returns scenario history entries, oldest first.
+func History(ctx context.Context, db *sql.DB, filter HistoryFilter) ([]HistoryEntry, error) {
+	const stmt = `SELECT id, scenario, regions, before, after, actor, outcome, COALESCE(error, ''), created_at
+								FROM (
+									SELECT *
+									FROM scenario_history
+									WHERE ($1 = '' OR scenario = $1)
+									AND ($2::TIMESTAMPTZ IS NULL OR created_at >= $2)
+									ORDER BY created_at DESC
+									LIMIT $3
+								) AS h
+								ORDER BY created_at`
+
+	since := sql.NullTime{Time: filter.Since, Valid: !filter.Since.IsZero()}
+
+	limit := int64(filter.Limit)
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+
+	rows, err := db.QueryContext(ctx, stmt, string(filter.Scenario), since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	types := pgtype.NewMap()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var beforeJSON, afterJSON []byte
+		if err = rows.Scan(&e.ID, &e.Scenario, types.SQLScanner(&e.Regions), &beforeJSON, &afterJSON, &e.Actor, &e.Outcome, &e.Error, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		if err = unmarshalSettings(beforeJSON, &e.Before); err != nil {
+			return nil, fmt.Errorf("parsing before: %w", err)
+		}
+
+		if err = unmarshalSettings(afterJSON, &e.After); err != nil {
+			return nil, fmt.Errorf("parsing after: %w", err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func insertHistory(ctx context.Context, db execer, e HistoryEntry) error {
+	const stmt = `INSERT INTO scenario_history (scenario, regions, before, after, actor, outcome, error)
+								VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))`
+
+	beforeJSON, err := marshalSettings(e.Before)
+	if err != nil {
+		return fmt.Errorf("marshalling before: %w", err)
+	}
+
+	afterJSON, err := marshalSettings(e.After)
+	if err != nil {
+		return fmt.Errorf("marshalling after: %w", err)
+	}
+
+	if _, err = db.ExecContext(ctx, stmt, string(e.Scenario), e.Regions, beforeJSON, afterJSON, e.Actor, string(e.Outcome), e.Error); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
+
+func marshalSettings(settings map[string]Settings) (sql.NullString, error) {
+	if settings == nil {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func unmarshalSettings(b []byte, settings *map[string]Settings) error {
+	if b == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, settings)
+}
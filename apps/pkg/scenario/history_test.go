@@ -0,0 +1,42 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]Settings
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name: "regions",
+			settings: map[string]Settings{
+				"europe-west2": {Workers: 1, Rate: 100},
+				"us-east1":     {Workers: 20, Rate: 50, Keys: "hotset=1:90"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := marshalSettings(tt.settings)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.settings != nil, stored.Valid)
+
+			var b []byte
+			if stored.Valid {
+				b = []byte(stored.String)
+			}
+
+			var got map[string]Settings
+			assert.NoError(t, unmarshalSettings(b, &got))
+			assert.Equal(t, tt.settings, got)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/ramp"
+)
+
+// Settings are the parts of a region's workload that scenarios change.
+type Settings struct {
+	Workers int    `json:"workers"`
+	Rate    int    `json:"rate"`
+	Keys    string `json:"keys,omitempty"`
+}
+
+func (s Settings) String() string {
+	if s.Keys == "" {
+		return fmt.Sprintf("%d workers @ %d/s", s.Workers, s.Rate)
+	}
+
+	return fmt.Sprintf("%d workers @ %d/s (%s keys)", s.Workers, s.Rate, s.Keys)
+}
+
+// shift moves current by the change from one of a window's settings to
+// another, so that changes made by anything else since (such as a
+// permanent scenario or an overlapping window) are kept. Workers can't
+// go below zero or the rate below one. The key distribution is only
+// changed if nothing else has changed it since from.
+func shift(current, from, to Settings) Settings {
+	next := Settings{
+		Workers: max(current.Workers+to.Workers-from.Workers, 0),
+		Rate:    max(current.Rate+to.Rate-from.Rate, 1),
+		Keys:    current.Keys,
+	}
+
+	if to.Keys != "" && current.Keys == from.Keys {
+		next.Keys = to.Keys
+	}
+
+	return next
+}
+
+// between returns the settings at a given level between from and to.
+// Key distributions can't be ramped between, so they switch to the
+// target's straight away.
+func between(from, to Settings, level float64) Settings {
+	return Settings{
+		Workers: ramp.Between(from.Workers, to.Workers, level),
+		Rate:    ramp.Between(from.Rate, to.Rate, level),
+		Keys:    to.Keys,
+	}
+}
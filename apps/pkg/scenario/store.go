@@ -0,0 +1,197 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// store is where the controller keeps workloads, windows and history.
+type store interface {
+	begin(ctx context.Context) (storeTx, error)
+	insertHistory(ctx context.Context, e HistoryEntry) error
+}
+
+// storeTx is a transaction against a store. Workloads and windows fetched
+// in it are locked until it's committed or rolled back.
+type storeTx interface {
+	fetchSettings(ctx context.Context, regions []string) (map[string]Settings, error)
+	updateSettings(ctx context.Context, region string, s Settings) error
+
+	fetchWindows(ctx context.Context) ([]windowRow, error)
+	insertWindow(ctx context.Context, row windowRow) error
+	updateApplied(ctx context.Context, id string, applied []byte) error
+	endWindow(ctx context.Context, id string) error
+
+	insertHistory(ctx context.Context, e HistoryEntry) error
+
+	commit() error
+	rollback() error
+}
+
+// windowRow is a window as it's stored, with its ramp profile and
+// settings as JSON. Duration is only used when inserting a window, and
+// elapsed, hasExpiry and expired are only set when fetching one.
+type windowRow struct {
+	id        string
+	scenario  string
+	duration  time.Duration
+	elapsed   time.Duration
+	hasExpiry bool
+	expired   bool
+	profile   []byte
+	revert    []byte
+	target    []byte
+	applied   []byte
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func (s postgresStore) begin(ctx context.Context) (storeTx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return postgresTx{tx: tx}, nil
+}
+
+func (s postgresStore) insertHistory(ctx context.Context, e HistoryEntry) error {
+	return insertHistory(ctx, s.db, e)
+}
+
+type postgresTx struct {
+	tx *sql.Tx
+}
+
+func (t postgresTx) fetchSettings(ctx context.Context, regions []string) (map[string]Settings, error) {
+	const stmt = `SELECT region, workers, rate, key_distribution
+								FROM workload
+								WHERE region = ANY($1)
+								FOR UPDATE`
+
+	rows, err := t.tx.QueryContext(ctx, stmt, regions)
+	if err != nil {
+		return nil, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	settings := map[string]Settings{}
+	for rows.Next() {
+		var region string
+		var s Settings
+		if err = rows.Scan(&region, &s.Workers, &s.Rate, &s.Keys); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		settings[region] = s
+	}
+
+	return settings, rows.Err()
+}
+
+// updateSettings updates a region's workload. Settings persisted before
+// key distributions were introduced have no keys, which leaves the
+// region's key distribution as it is.
+func (t postgresTx) updateSettings(ctx context.Context, region string, s Settings) error {
+	const stmt = `UPDATE workload
+								SET workers = $1, rate = $2, key_distribution = COALESCE(NULLIF($3, ''), key_distribution)
+								WHERE region = $4`
+
+	if _, err := t.tx.ExecContext(ctx, stmt, s.Workers, s.Rate, s.Keys, region); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
+
+func (t postgresTx) fetchWindows(ctx context.Context) ([]windowRow, error) {
+	const stmt = `SELECT
+									id,
+									scenario,
+									extract(epoch FROM now() - started_at),
+									expires_at IS NOT NULL,
+									COALESCE(expires_at <= now(), false),
+									profile,
+									revert,
+									target,
+									applied
+								FROM scenario_window
+								WHERE ended_at IS NULL
+								ORDER BY started_at
+								FOR UPDATE`
+
+	rows, err := t.tx.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []windowRow
+	for rows.Next() {
+		var w windowRow
+		var elapsedSeconds float64
+		if err = rows.Scan(&w.id, &w.scenario, &elapsedSeconds, &w.hasExpiry, &w.expired, &w.profile, &w.revert, &w.target, &w.applied); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		w.elapsed = time.Duration(elapsedSeconds * float64(time.Second))
+
+		windows = append(windows, w)
+	}
+
+	return windows, rows.Err()
+}
+
+func (t postgresTx) insertWindow(ctx context.Context, row windowRow) error {
+	const stmt = `INSERT INTO scenario_window (scenario, expires_at, profile, revert, target, applied)
+								VALUES ($1, now() + $2 * INTERVAL '1 microsecond', $3, $4, $5, $6)`
+
+	duration := sql.NullInt64{
+		Int64: row.duration.Microseconds(),
+		Valid: row.duration > 0,
+	}
+
+	if _, err := t.tx.ExecContext(ctx, stmt, row.scenario, duration, string(row.profile), string(row.revert), string(row.target), string(row.applied)); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
+
+func (t postgresTx) updateApplied(ctx context.Context, id string, applied []byte) error {
+	const stmt = `UPDATE scenario_window
+								SET applied = $2
+								WHERE id = $1`
+
+	if _, err := t.tx.ExecContext(ctx, stmt, id, string(applied)); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
+
+func (t postgresTx) endWindow(ctx context.Context, id string) error {
+	const stmt = `UPDATE scenario_window
+								SET ended_at = now()
+								WHERE id = $1`
+
+	if _, err := t.tx.ExecContext(ctx, stmt, id); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	return nil
+}
+
+func (t postgresTx) insertHistory(ctx context.Context, e HistoryEntry) error {
+	return insertHistory(ctx, t.tx, e)
+}
+
+func (t postgresTx) commit() error {
+	return t.tx.Commit()
+}
+
+func (t postgresTx) rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+// Distribution describes how account balances are generated. Uniform
+// balances lie between Min and Max, normal balances have a Mean and
+// StdDev, and exponential balances have a Mean, giving many small
+// balances and a few large ones. Balances are rounded to 2 decimal places
+// and are never negative.
+type Distribution struct {
+	Kind   string
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+}
+
+func (d Distribution) Validate() error {
+	switch d.Kind {
+	case DistributionUniform:
+		if d.Min < 0 || d.Max < d.Min {
+			return fmt.Errorf("uniform balances need 0 <= min <= max")
+		}
+
+	case DistributionNormal:
+		if d.Mean < 0 || d.StdDev < 0 {
+			return fmt.Errorf("normal balances need a non-negative mean and standard deviation")
+		}
+
+	case DistributionExponential:
+		if d.Mean <= 0 {
+			return fmt.Errorf("exponential balances need a positive mean")
+		}
+
+	default:
+		return fmt.Errorf("invalid distribution: %q", d.Kind)
+	}
+
+	return nil
+}
+
+// Sample returns a balance drawn from the distribution.
+func (d Distribution) Sample(rng *rand.Rand) float64 {
+	var v float64
+	switch d.Kind {
+	case DistributionNormal:
+		v = d.Mean + rng.NormFloat64()*d.StdDev
+	case DistributionExponential:
+		v = rng.ExpFloat64() * d.Mean
+	default:
+		v = d.Min + rng.Float64()*(d.Max-d.Min)
+	}
+
+	return math.Max(math.Round(v*100)/100, 0)
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Distribution
+		wantErr string
+	}{
+		{
+			name: "uniform",
+			d:    Distribution{Kind: DistributionUniform, Min: 0, Max: 10000},
+		},
+		{
+			name:    "uniform with max below min",
+			d:       Distribution{Kind: DistributionUniform, Min: 10, Max: 5},
+			wantErr: "uniform balances need 0 <= min <= max",
+		},
+		{
+			name: "normal",
+			d:    Distribution{Kind: DistributionNormal, Mean: 5000, StdDev: 1000},
+		},
+		{
+			name:    "normal with negative standard deviation",
+			d:       Distribution{Kind: DistributionNormal, Mean: 5000, StdDev: -1},
+			wantErr: "normal balances need a non-negative mean and standard deviation",
+		},
+		{
+			name:    "exponential without mean",
+			d:       Distribution{Kind: DistributionExponential},
+			wantErr: "exponential balances need a positive mean",
+		},
+		{
+			name:    "unknown",
+			d:       Distribution{Kind: "pareto"},
+			wantErr: `invalid distribution: "pareto"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.d.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDistributionSample(t *testing.T) {
+	tests := []struct {
+		name     string
+		d        Distribution
+		wantMean float64
+		wantMin  float64
+		wantMax  float64
+	}{
+		{
+			name:     "uniform",
+			d:        Distribution{Kind: DistributionUniform, Min: 100, Max: 200},
+			wantMean: 150,
+			wantMin:  100,
+			wantMax:  200,
+		},
+		{
+			name:     "normal",
+			d:        Distribution{Kind: DistributionNormal, Mean: 5000, StdDev: 100},
+			wantMean: 5000,
+			wantMin:  0,
+			wantMax:  math.Inf(1),
+		},
+		{
+			name:     "exponential",
+			d:        Distribution{Kind: DistributionExponential, Mean: 1000},
+			wantMean: 1000,
+			wantMin:  0,
+			wantMax:  math.Inf(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(1, 2))
+
+			const n = 10000
+			var sum float64
+			for range n {
+				v := tt.d.Sample(rng)
+				assert.GreaterOrEqual(t, v, tt.wantMin)
+				assert.LessOrEqual(t, v, tt.wantMax)
+				assert.Equal(t, math.Round(v*100)/100, v, "balance should have 2 decimal places")
+				sum += v
+			}
+
+			assert.InEpsilon(t, tt.wantMean, sum/n, 0.05)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DatabaseRegions returns the database's primary region and all of its
+// regions. A database that isn't multi-region has no regions.
+func DatabaseRegions(ctx context.Context, db *sql.DB) (primary string, regions []string, err error) {
+	const stmt = `SELECT region, "primary"
+								FROM [SHOW REGIONS FROM DATABASE]
+								ORDER BY region`
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return "", nil, fmt.Errorf("making query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var region string
+		var isPrimary bool
+		if err = rows.Scan(&region, &isPrimary); err != nil {
+			return "", nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		if isPrimary {
+			primary = region
+		}
+		regions = append(regions, region)
+	}
+
+	return primary, regions, rows.Err()
+}
+
+// MakeMultiRegion makes the database multi-region with the given primary
+// region and regions, makes the account table REGIONAL BY ROW and spreads
+// its rows evenly across the regions. Steps that have already been made
+// are skipped and each step is verified once made, so it can be rerun
+// after a partial failure.
+func MakeMultiRegion(ctx context.Context, db *sql.DB, primary string, regions []string) error {
+	if !slices.Contains(regions, primary) {
+		regions = append([]string{primary}, regions...)
+	}
+
+	database, err := currentDatabase(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching database: %w", err)
+	}
+
+	current, existing, err := DatabaseRegions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching regions: %w", err)
+	}
+
+	for _, c := range planRegions(database, primary, regions, current, existing) {
+		if c.primary != "" {
+			if err = step(ctx, db, c.stmt, func() (bool, error) {
+				current, _, err := DatabaseRegions(ctx, db)
+				return current == c.primary, err
+			}); err != nil {
+				return fmt.Errorf("setting primary region: %w", err)
+			}
+			continue
+		}
+
+		if err = step(ctx, db, c.stmt, func() (bool, error) {
+			_, regions, err := DatabaseRegions(ctx, db)
+			return slices.Contains(regions, c.region), err
+		}); err != nil {
+			return fmt.Errorf("adding region %s: %w", c.region, err)
+		}
+	}
+
+	rbr, err := regionalByRow(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching account locality: %w", err)
+	}
+
+	if !rbr {
+		if err = step(ctx, db, "ALTER TABLE account SET LOCALITY REGIONAL BY ROW", func() (bool, error) {
+			return regionalByRow(ctx, db)
+		}); err != nil {
+			return fmt.Errorf("setting account locality: %w", err)
+		}
+	}
+
+	accounts, spread, err := accountRegions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching account regions: %w", err)
+	}
+
+	// Only spread accounts that haven't already been spread, so that
+	// rerunning doesn't move rows between regions mid-game.
+	if spread < min(accounts, len(regions)) {
+		if err = spreadAccounts(ctx, db, regions); err != nil {
+			return fmt.Errorf("spreading accounts: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// regionChange is a statement that either sets the database's primary
+// region or adds a region to it.
+type regionChange struct {
+	stmt    string
+	primary string
+	region  string
+}
+
+// planRegions returns the statements needed to give a database the
+// primary region and regions, given its current primary region and
+// regions. A database that isn't multi-region needs a primary region
+// before any others can be added, whereas a multi-region database's
+// primary region can only be moved to a region it already has.
+func planRegions(database, primary string, regions []string, current string, existing []string) []regionChange {
+	setPrimary := regionChange{
+		stmt:    fmt.Sprintf("ALTER DATABASE %s SET PRIMARY REGION %s", database, pgx.Identifier{primary}.Sanitize()),
+		primary: primary,
+	}
+
+	var changes []regionChange
+	if current == "" {
+		changes = append(changes, setPrimary)
+		existing = append(existing, primary)
+	}
+
+	for _, region := range regions {
+		if slices.Contains(existing, region) {
+			continue
+		}
+
+		changes = append(changes, regionChange{
+			stmt:   fmt.Sprintf("ALTER DATABASE %s ADD REGION %s", database, pgx.Identifier{region}.Sanitize()),
+			region: region,
+		})
+	}
+
+	if current != "" && current != primary {
+		changes = append(changes, setPrimary)
+	}
+
+	return changes
+}
+
+// MakeSingleRegion reverts MakeMultiRegion, making the account table
+// REGIONAL BY TABLE, dropping its crdb_region column and then dropping
+// every region from the database, primary region last. Steps that have
+// already been made are skipped, so it can be rerun after a partial
+// failure.
+func MakeSingleRegion(ctx context.Context, db *sql.DB) error {
+	database, err := currentDatabase(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching database: %w", err)
+	}
+
+	rbr, err := regionalByRow(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching account locality: %w", err)
+	}
+
+	if rbr {
+		if err = step(ctx, db, "ALTER TABLE account SET LOCALITY REGIONAL BY TABLE IN PRIMARY REGION", func() (bool, error) {
+			rbr, err := regionalByRow(ctx, db)
+			return !rbr, err
+		}); err != nil {
+			return fmt.Errorf("setting account locality: %w", err)
+		}
+	}
+
+	hasColumn, err := hasRegionColumn(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching account columns: %w", err)
+	}
+
+	if hasColumn {
+		if err = step(ctx, db, "ALTER TABLE account DROP COLUMN crdb_region", func() (bool, error) {
+			hasColumn, err := hasRegionColumn(ctx, db)
+			return !hasColumn, err
+		}); err != nil {
+			return fmt.Errorf("dropping region column: %w", err)
+		}
+	}
+
+	primary, regions, err := DatabaseRegions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching regions: %w", err)
+	}
+
+	// The primary region can only be dropped once it's the last region.
+	regions = slices.DeleteFunc(regions, func(r string) bool { return r == primary })
+	if primary != "" {
+		regions = append(regions, primary)
+	}
+
+	for _, region := range regions {
+		stmt := fmt.Sprintf("ALTER DATABASE %s DROP REGION %s", database, pgx.Identifier{region}.Sanitize())
+		if err = step(ctx, db, stmt, func() (bool, error) {
+			_, regions, err := DatabaseRegions(ctx, db)
+			return !slices.Contains(regions, region), err
+		}); err != nil {
+			return fmt.Errorf("dropping region %s: %w", region, err)
+		}
+	}
+
+	return nil
+}
+
+// step runs a schema change and checks that it took effect.
+func step(ctx context.Context, db *sql.DB, stmt string, verify func() (bool, error)) error {
+	log.Printf("running: %s", stmt)
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	ok, err := verify()
+	if err != nil {
+		return fmt.Errorf("verifying: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("change not applied: %s", stmt)
+	}
+
+	return nil
+}
+
+func currentDatabase(ctx context.Context, db *sql.DB) (string, error) {
+	const stmt = `SELECT current_database()`
+
+	var name string
+	if err := db.QueryRowContext(ctx, stmt).Scan(&name); err != nil {
+		return "", fmt.Errorf("scanning row: %w", err)
+	}
+
+	return pgx.Identifier{name}.Sanitize(), nil
+}
+
+func regionalByRow(ctx context.Context, db *sql.DB) (bool, error) {
+	const stmt = `SELECT create_statement
+								FROM [SHOW CREATE TABLE account]`
+
+	var create string
+	if err := db.QueryRowContext(ctx, stmt).Scan(&create); err != nil {
+		return false, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return strings.Contains(create, "REGIONAL BY ROW"), nil
+}
+
+func hasRegionColumn(ctx context.Context, db *sql.DB) (bool, error) {
+	const stmt = `SELECT count(*) > 0
+								FROM information_schema.columns
+								WHERE table_catalog = current_database()
+								AND table_name = 'account'
+								AND column_name = 'crdb_region'`
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, stmt).Scan(&exists); err != nil {
+		return false, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return exists, nil
+}
+
+// accountRegions returns the number of accounts and the number of
+// regions they're spread over.
+func accountRegions(ctx context.Context, db *sql.DB) (accounts, regions int, err error) {
+	const stmt = `SELECT count(*), count(DISTINCT crdb_region)
+								FROM account`
+
+	if err = db.QueryRowContext(ctx, stmt).Scan(&accounts, &regions); err != nil {
+		return 0, 0, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return accounts, regions, nil
+}
+
+// spreadAccounts assigns each account to a region using a hash of its
+// id, so that accounts are spread evenly and always to the same region.
+func spreadAccounts(ctx context.Context, db *sql.DB, regions []string) error {
+	const stmt = `UPDATE account
+								SET crdb_region = ($1::STRING[])[1 + (fnv32(id::STRING) % array_length($1::STRING[], 1))]::crdb_internal_region`
+
+	log.Printf("spreading accounts across %s", strings.Join(regions, ", "))
+
+	if _, err := db.ExecContext(ctx, stmt, regions); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	accounts, spread, err := accountRegions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("verifying: %w", err)
+	}
+
+	if spread < min(accounts, len(regions)) {
+		return fmt.Errorf("accounts only spread across %d of %d regions", spread, len(regions))
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRegions(t *testing.T) {
+	regions := []string{"gcp-europe-west2", "gcp-asia-southeast1", "gcp-us-east1"}
+
+	tests := []struct {
+		name     string
+		primary  string
+		current  string
+		existing []string
+		want     []string
+	}{
+		{
+			name:    "single region",
+			primary: "gcp-europe-west2",
+			want: []string{
+				`ALTER DATABASE "db" SET PRIMARY REGION "gcp-europe-west2"`,
+				`ALTER DATABASE "db" ADD REGION "gcp-asia-southeast1"`,
+				`ALTER DATABASE "db" ADD REGION "gcp-us-east1"`,
+			},
+		},
+		{
+			name:     "partially multi-region",
+			primary:  "gcp-europe-west2",
+			current:  "gcp-europe-west2",
+			existing: []string{"gcp-europe-west2", "gcp-us-east1"},
+			want: []string{
+				`ALTER DATABASE "db" ADD REGION "gcp-asia-southeast1"`,
+			},
+		},
+		{
+			name:     "primary moved to a new region",
+			primary:  "gcp-europe-west2",
+			current:  "gcp-us-east1",
+			existing: []string{"gcp-us-east1"},
+			want: []string{
+				`ALTER DATABASE "db" ADD REGION "gcp-europe-west2"`,
+				`ALTER DATABASE "db" ADD REGION "gcp-asia-southeast1"`,
+				`ALTER DATABASE "db" SET PRIMARY REGION "gcp-europe-west2"`,
+			},
+		},
+		{
+			name:     "already multi-region",
+			primary:  "gcp-europe-west2",
+			current:  "gcp-europe-west2",
+			existing: regions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range planRegions(`"db"`, tt.primary, regions, tt.current, tt.existing) {
+				got = append(got, c.stmt)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
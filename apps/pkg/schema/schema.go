@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/rand/v2"
+)
+
+// statements create every table used by the workload and the wheel. Each
+// can be run against a database that already has the table.
+var statements = []string{
+	`CREATE TABLE IF NOT EXISTS workload (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		region STRING NOT NULL,
+		workers INT NOT NULL DEFAULT 0,
+		rate INT NOT NULL DEFAULT 100,
+		think_time STRING NOT NULL DEFAULT 'fixed',
+		mix STRING NOT NULL DEFAULT 'transfer=100',
+		key_distribution STRING NOT NULL DEFAULT 'uniform'
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS scenario_window (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		scenario STRING NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ,
+		profile JSONB NOT NULL,
+		revert JSONB NOT NULL,
+		target JSONB NOT NULL,
+		applied JSONB NOT NULL,
+		ended_at TIMESTAMPTZ
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS scenario_history (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		scenario STRING NOT NULL,
+		regions STRING[] NOT NULL,
+		before JSONB,
+		after JSONB,
+		actor STRING NOT NULL,
+		outcome STRING NOT NULL,
+		error STRING,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS game_round (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		scenario STRING NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at TIMESTAMPTZ NOT NULL,
+		target FLOAT NOT NULL,
+		score FLOAT NOT NULL,
+		samples INT NOT NULL,
+		passed BOOL NOT NULL
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS account (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		balance DECIMAL NOT NULL
+	)`,
+
+	// The total balance across all accounts, which transfers conserve.
+	`CREATE TABLE IF NOT EXISTS account_total (
+		id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+		expected DECIMAL NOT NULL
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS transaction_history (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		account_id UUID NOT NULL,
+		amount DECIMAL NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+}
+
+// migrations bring tables created by earlier versions of statements (or
+// by hand, from older versions of the README) up to date. Each can be run
+// against a table that's already up to date.
+var migrations = []string{
+	`ALTER TABLE workload ADD COLUMN IF NOT EXISTS rate INT NOT NULL DEFAULT 100`,
+	`ALTER TABLE workload ADD COLUMN IF NOT EXISTS think_time STRING NOT NULL DEFAULT 'fixed'`,
+	`ALTER TABLE workload ADD COLUMN IF NOT EXISTS mix STRING NOT NULL DEFAULT 'transfer=100'`,
+	`ALTER TABLE workload ADD COLUMN IF NOT EXISTS key_distribution STRING NOT NULL DEFAULT 'uniform'`,
+}
+
+// accountBatchSize is the number of accounts inserted per statement.
+const accountBatchSize = 1000
+
+// Create creates any tables that don't already exist and adds any
+// columns missing from tables that do.
+func Create(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("making request: %w", err)
+		}
+	}
+
+	for _, stmt := range migrations {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrating: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SeedWorkload inserts a workload row (with no workers) for each region
+// that doesn't already have one, returning the number inserted.
+func SeedWorkload(ctx context.Context, db *sql.DB, regions []string) (int, error) {
+	const stmt = `INSERT INTO workload (region)
+								SELECT r
+								FROM unnest($1::STRING[]) AS r
+								WHERE NOT EXISTS (
+									SELECT 1 FROM workload WHERE region = r
+								)`
+
+	res, err := db.ExecContext(ctx, stmt, regions)
+	if err != nil {
+		return 0, fmt.Errorf("making request: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("counting rows: %w", err)
+	}
+
+	return int(inserted), nil
+}
+
+// SeedAccounts tops up the account table to count accounts, with
+// balances drawn from the given distribution, returning the number
+// inserted. The expected total balance is increased by the balances of
+// the accounts inserted, or set to the current total balance if it
+// hasn't been recorded before.
+func SeedAccounts(ctx context.Context, db *sql.DB, count int, balances Distribution) (int, error) {
+	const totalStmt = `INSERT INTO account_total (id, expected)
+										SELECT 1, COALESCE(sum(balance), 0)
+										FROM account
+										ON CONFLICT (id) DO NOTHING`
+
+	const countStmt = `SELECT count(*) FROM account`
+
+	if _, err := db.ExecContext(ctx, totalStmt); err != nil {
+		return 0, fmt.Errorf("recording expected total: %w", err)
+	}
+
+	var existing int
+	if err := db.QueryRowContext(ctx, countStmt).Scan(&existing); err != nil {
+		return 0, fmt.Errorf("counting accounts: %w", err)
+	}
+
+	remaining := count - existing
+	if remaining <= 0 {
+		return 0, nil
+	}
+
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+	var inserted int
+	for inserted < remaining {
+		batch := make([]float64, min(accountBatchSize, remaining-inserted))
+		for i := range batch {
+			batch[i] = balances.Sample(rng)
+		}
+
+		if err := insertAccounts(ctx, db, batch); err != nil {
+			return inserted, fmt.Errorf("inserting accounts: %w", err)
+		}
+
+		inserted += len(batch)
+		log.Printf("inserted %d / %d accounts", inserted, remaining)
+	}
+
+	return inserted, nil
+}
+
+// insertAccounts inserts accounts with the given balances and adds them
+// to the expected total balance, in a single transaction.
+func insertAccounts(ctx context.Context, db *sql.DB, balances []float64) error {
+	const insertStmt = `INSERT INTO account (balance)
+											SELECT unnest($1::DECIMAL[])`
+
+	const totalStmt = `UPDATE account_total
+										SET expected = expected + (SELECT sum(b) FROM unnest($1::DECIMAL[]) AS b)
+										WHERE id = 1`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, insertStmt, balances); err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, totalStmt, balances); err != nil {
+		return fmt.Errorf("updating expected total: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
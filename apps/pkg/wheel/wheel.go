@@ -0,0 +1,159 @@
+package wheel
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+)
+
+const (
+	// friction is the proportion of angular velocity kept each step.
+	friction = 0.97
+
+	// stopVelocity is the angular velocity below which the wheel stops.
+	stopVelocity = 0.002
+)
+
+// Wheel models the spin physics of the scenario wheel, independently of
+// how (or whether) it's drawn. Each call to Step advances the wheel by
+// one frame.
+type Wheel struct {
+	segments []scenario.Definition
+	bounds   []float64
+	angle    float64
+	angVel   float64
+	spinning bool
+}
+
+func New(segments []scenario.Definition) *Wheel {
+	return &Wheel{
+		segments: segments,
+		bounds:   segmentBounds(segments),
+	}
+}
+
+// Segments returns the scenarios on the wheel.
+func (w *Wheel) Segments() []scenario.Definition {
+	return w.segments
+}
+
+// Bounds returns the angle at which each segment starts, followed by 2π.
+func (w *Wheel) Bounds() []float64 {
+	return w.bounds
+}
+
+// Angle returns the wheel's current rotation in radians.
+func (w *Wheel) Angle() float64 {
+	return w.angle
+}
+
+func (w *Wheel) Spinning() bool {
+	return w.spinning
+}
+
+// Spin starts the wheel spinning from a random angle and velocity. It
+// has no effect if the wheel is already spinning.
+func (w *Wheel) Spin() {
+	if w.spinning {
+		return
+	}
+
+	w.spinning = true
+	w.angVel = 0.4 + rand.Float64()*0.6
+	w.angle += rand.Float64() * 2 * math.Pi
+}
+
+// Step advances a spinning wheel by one frame, returning the scenario
+// under the pointer and true on the frame it comes to rest.
+func (w *Wheel) Step() (models.Scenario, bool) {
+	if !w.spinning {
+		return "", false
+	}
+
+	w.angle += w.angVel
+	w.angVel *= friction
+	if w.angVel >= stopVelocity {
+		return "", false
+	}
+
+	w.spinning = false
+	w.angVel = 0
+	return w.SegmentAtPointer(), true
+}
+
+// Simulate spins the wheel and steps it until it comes to rest,
+// returning the scenario it landed on.
+func (w *Wheel) Simulate() models.Scenario {
+	w.Spin()
+	for {
+		if landed, ok := w.Step(); ok {
+			return landed
+		}
+	}
+}
+
+// SegmentAtPointer returns the scenario under the pointer. As spins start
+// from a random angle, the chance of landing on a segment is proportional
+// to its weight.
+func (w *Wheel) SegmentAtPointer() models.Scenario {
+	n := len(w.segments)
+	if n == 0 {
+		return ""
+	}
+	a := math.Mod(-math.Pi/2-w.angle, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	idx := sort.Search(n, func(i int) bool { return w.bounds[i+1] > a })
+	if idx >= n {
+		idx = n - 1
+	}
+	return w.segments[idx].Name
+}
+
+// segmentBounds returns the angle at which each segment starts, followed
+// by 2π, sizing each segment's arc in proportion to its weight.
+func segmentBounds(segments []scenario.Definition) []float64 {
+	var total float64
+	for _, s := range segments {
+		total += s.WheelWeight()
+	}
+
+	bounds := make([]float64, len(segments)+1)
+	for i, s := range segments {
+		bounds[i+1] = bounds[i] + 2*math.Pi*s.WheelWeight()/total
+	}
+	bounds[len(segments)] = 2 * math.Pi
+
+	return bounds
+}
+
+// ParseWeights parses weights in the form "scenario=weight,...".
+func ParseWeights(s string) (map[models.Scenario]float64, error) {
+	weights := map[models.Scenario]float64{}
+	if s == "" {
+		return weights, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected scenario=weight", pair)
+		}
+
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for %s: %w", name, err)
+		}
+
+		weights[models.Scenario(strings.TrimSpace(name))] = w
+	}
+
+	return weights, nil
+}
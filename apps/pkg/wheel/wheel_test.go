@@ -0,0 +1,67 @@
+package wheel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentAtPointer(t *testing.T) {
+	three := 3.0
+	w := New([]scenario.Definition{
+		{Name: "a"},
+		{Name: "b", Weight: &three},
+	})
+
+	tests := []struct {
+		name  string
+		angle float64
+		want  models.Scenario
+	}{
+		{name: "start of a", angle: -math.Pi / 2, want: "a"},
+		{name: "end of a", angle: -math.Pi/2 - math.Pi/2 + 0.01, want: "a"},
+		{name: "start of b", angle: -math.Pi/2 - math.Pi/2 - 0.01, want: "b"},
+		{name: "end of b", angle: -math.Pi/2 + 0.01, want: "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.angle = tt.angle
+			assert.Equal(t, tt.want, w.SegmentAtPointer())
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	var zero float64
+	w := New([]scenario.Definition{
+		{Name: "a"},
+		{Name: "never", Weight: &zero},
+		{Name: "b"},
+	})
+
+	landed := map[models.Scenario]int{}
+	for range 1000 {
+		landed[w.Simulate()]++
+		assert.False(t, w.Spinning())
+	}
+
+	assert.Zero(t, landed["never"])
+	assert.InDelta(t, 500, landed["a"], 100)
+	assert.InDelta(t, 500, landed["b"], 100)
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ParseWeights("flash-sale=3, test=0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[models.Scenario]float64{"flash-sale": 3, "test": 0}, weights)
+
+	_, err = ParseWeights("flash-sale")
+	assert.Error(t, err)
+
+	_, err = ParseWeights("flash-sale=lots")
+	assert.Error(t, err)
+}
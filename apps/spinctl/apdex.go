@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/monitor"
+)
+
+type regionApdex struct {
+	Region  string        `json:"region"`
+	Score   float64       `json:"score"`
+	Samples int64         `json:"samples"`
+	Summary apdex.Summary `json:"summary"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func runApdex(args []string) error {
+	fs := flag.NewFlagSet("apdex", flag.ExitOnError)
+	apURL := fs.String("ap-url", os.Getenv("AP_SERVICE_URL"), "url of the AP workload service")
+	euURL := fs.String("eu-url", os.Getenv("EU_SERVICE_URL"), "url of the EU workload service")
+	usURL := fs.String("us-url", os.Getenv("US_SERVICE_URL"), "url of the US workload service")
+	window := fs.String("window", "1m", "window to score (10s, 1m, 5m or 10m)")
+	asJSON := fs.Bool("json", false, "output scores as JSON")
+	fs.Parse(args)
+
+	services := monitor.Services(*apURL, *euURL, *usURL)
+	if len(services) == 0 {
+		return fmt.Errorf("missing --ap-url, --eu-url or --us-url")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	client := &http.Client{Timeout: time.Second * 5}
+
+	var regions []regionApdex
+	for _, f := range monitor.FetchAll(ctx, client, services) {
+		regions = append(regions, newRegionApdex(f, *window))
+	}
+
+	var summaries []apdex.Summary
+	for _, r := range regions {
+		if r.Error == "" {
+			summaries = append(summaries, r.Summary)
+		}
+	}
+
+	global := apdex.Aggregate(summaries...)
+	regions = append(regions, regionApdex{
+		Region:  "global",
+		Score:   global.Score(),
+		Samples: global.Samples(),
+		Summary: global,
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(regions)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGION\tSCORE\tGRADE\tSAMPLES\tSATISFIED\tTOLERATING\tFRUSTRATED")
+	for _, r := range regions {
+		if r.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t%s\n", r.Region, r.Error)
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\t%.2f\t%s\t%d\t%d\t%d\t%d\n", r.Region, r.Score, apdex.Grade(r.Score), r.Samples, r.Summary.Satisfied, r.Summary.Tolerating, r.Summary.Frustrated)
+	}
+
+	return tw.Flush()
+}
+
+// newRegionApdex returns the summary of the given window fetched from a
+// region.
+func newRegionApdex(f monitor.Fetched, window string) regionApdex {
+	r := regionApdex{Region: f.Region}
+	if f.Err != nil {
+		r.Error = f.Err.Error()
+		return r
+	}
+
+	summary, ok := f.Status.Window(window)
+	if !ok {
+		r.Error = fmt.Sprintf("no %s window", window)
+		return r
+	}
+
+	r.Summary = summary
+	r.Score = summary.Score()
+	r.Samples = summary.Samples()
+
+	return r
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/invariant"
+)
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	dbURL := fs.String("url", "", "url to the database")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	db, err := openDB(*dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	r, err := invariant.Check(ctx, db)
+	if err != nil {
+		return fmt.Errorf("checking balances: %w", err)
+	}
+
+	if *asJSON {
+		if err = json.NewEncoder(os.Stdout).Encode(r); err != nil {
+			return fmt.Errorf("encoding result: %w", err)
+		}
+	} else {
+		fmt.Printf("at:       %s\n", r.At.Format(time.RFC3339))
+		fmt.Printf("accounts: %d\n", r.Accounts)
+		fmt.Printf("expected: %s\n", r.Expected)
+		fmt.Printf("actual:   %s\n", r.Actual)
+		fmt.Printf("drift:    %s\n", r.Drift)
+	}
+
+	if !r.Balanced {
+		return fmt.Errorf("balances have drifted by %s", r.Drift)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+)
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	dbURL := fs.String("url", "", "url to the database")
+	name := fs.String("scenario", "", "only show entries for this scenario")
+	since := fs.Duration("since", 0, "only show entries from this far back (e.g. 1h)")
+	limit := fs.Int("limit", 50, "maximum number of entries to show (0 for all)")
+	asJSON := fs.Bool("json", false, "output entries as JSON")
+	fs.Parse(args)
+
+	db, err := openDB(*dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter := scenario.HistoryFilter{
+		Scenario: models.Scenario(*name),
+		Limit:    *limit,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	entries, err := scenario.History(ctx, db, filter)
+	if err != nil {
+		return fmt.Errorf("fetching history: %w", err)
+	}
+
+	return writeHistory(os.Stdout, entries, *asJSON)
+}
+
+// writeHistory writes entries as a table, or as JSON if asJSON is set.
+func writeHistory(w io.Writer, entries []scenario.HistoryEntry, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSCENARIO\tOUTCOME\tACTOR\tSETTINGS")
+	for _, e := range entries {
+		settings := formatSettings(e)
+		if e.Error != "" {
+			settings = e.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.CreatedAt.Local().Format(time.DateTime), e.Scenario, e.Outcome, e.Actor, settings)
+	}
+
+	return tw.Flush()
+}
+
+func formatSettings(e scenario.HistoryEntry) string {
+	regions := slices.Clone(e.Regions)
+	slices.Sort(regions)
+
+	var parts []string
+	for _, region := range regions {
+		parts = append(parts, fmt.Sprintf("%s: %s -> %s", region, e.Before[region], e.After[region]))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteHistory(t *testing.T) {
+	entries := []scenario.HistoryEntry{
+		{
+			Scenario: "flash-sale",
+			Regions:  []string{"us-east1", "europe-west2"},
+			Before: map[string]scenario.Settings{
+				"europe-west2": {Workers: 1, Rate: 100},
+				"us-east1":     {Workers: 2, Rate: 100},
+			},
+			After: map[string]scenario.Settings{
+				"europe-west2": {Workers: 10, Rate: 100},
+				"us-east1":     {Workers: 20, Rate: 100, Keys: "hotset=1:90"},
+			},
+			Actor:     "wheel",
+			Outcome:   scenario.OutcomeApplied,
+			CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local),
+		},
+		{
+			Scenario:  "scandal",
+			Regions:   []string{"us-east1"},
+			Actor:     "spinctl",
+			Outcome:   scenario.OutcomeFailed,
+			Error:     "region not found",
+			CreatedAt: time.Date(2025, 1, 2, 3, 5, 0, 0, time.Local),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		entries []scenario.HistoryEntry
+		want    string
+	}{
+		{
+			name: "no entries",
+			want: "TIME  SCENARIO  OUTCOME  ACTOR  SETTINGS\n",
+		},
+		{
+			name:    "entries",
+			entries: entries,
+			want: "TIME                 SCENARIO    OUTCOME  ACTOR    SETTINGS\n" +
+				"2025-01-02 03:04:05  flash-sale  applied  wheel    europe-west2: 1 workers @ 100/s -> 10 workers @ 100/s, us-east1: 2 workers @ 100/s -> 20 workers @ 100/s (hotset=1:90 keys)\n" +
+				"2025-01-02 03:05:00  scandal     failed   spinctl  region not found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, writeHistory(&buf, tt.entries, false))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteHistoryJSON(t *testing.T) {
+	entries := []scenario.HistoryEntry{
+		{
+			ID:        "1",
+			Scenario:  "scandal",
+			Regions:   []string{"us-east1"},
+			Before:    map[string]scenario.Settings{"us-east1": {Workers: 1, Rate: 100}},
+			After:     map[string]scenario.Settings{"us-east1": {Workers: 0, Rate: 100}},
+			Actor:     "wheel",
+			Outcome:   scenario.OutcomeApplied,
+			CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeHistory(&buf, entries, true))
+
+	var got []scenario.HistoryEntry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, entries, got)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"apdex":   {description: "show the Apdex score of each region and globally", run: runApdex},
+	"check":   {description: "check that the total balance of every account is conserved", run: runCheck},
+	"history": {description: "show the history of applied scenarios", run: runHistory},
+	"migrate": {description: "make the database multi-region (or revert it to single-region)", run: runMigrate},
+	"setup":   {description: "create the schema and seed workload and account data", run: runSetup},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: spinctl <command> [flags]\n\nCommands:\n")
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+}
+
+func openDB(url string) (*sql.DB, error) {
+	if url == "" {
+		return nil, fmt.Errorf("missing --url")
+	}
+
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database connection: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/schema"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbURL := fs.String("url", "", "url to the database")
+	primary := fs.String("primary", models.RegionEU, "primary region of the database")
+	regions := fs.String("regions", strings.Join([]string{models.RegionAP, models.RegionEU, models.RegionUS}, ","), "comma-separated regions to add to the database")
+	revert := fs.Bool("revert", false, "revert the database to a single region")
+	fs.Parse(args)
+
+	db, err := openDB(*dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Schema changes that backfill data can take a while.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*30)
+	defer cancel()
+
+	if *revert {
+		if err = schema.MakeSingleRegion(ctx, db); err != nil {
+			return fmt.Errorf("reverting to single-region: %w", err)
+		}
+		log.Printf("database is single-region")
+		return nil
+	}
+
+	if err = schema.MakeMultiRegion(ctx, db, *primary, splitList(*regions)); err != nil {
+		return fmt.Errorf("migrating to multi-region: %w", err)
+	}
+
+	current, all, err := schema.DatabaseRegions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching regions: %w", err)
+	}
+	log.Printf("database is multi-region (primary: %s, regions: %s)", current, strings.Join(all, ", "))
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/schema"
+)
+
+func runSetup(args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	dbURL := fs.String("url", "", "url to the database")
+	regions := fs.String("regions", strings.Join([]string{models.RegionAP, models.RegionEU, models.RegionUS}, ","), "comma-separated regions to create workload rows for")
+	accounts := fs.Int("accounts", 1000, "number of accounts the account table should contain")
+	balance := fs.String("balance", schema.DistributionUniform, "distribution of account balances (uniform, normal or exponential)")
+	balanceMin := fs.Float64("balance-min", 0, "minimum balance, for uniform balances")
+	balanceMax := fs.Float64("balance-max", 10000, "maximum balance, for uniform balances")
+	balanceMean := fs.Float64("balance-mean", 5000, "mean balance, for normal and exponential balances")
+	balanceStdDev := fs.Float64("balance-stddev", 1000, "standard deviation of balances, for normal balances")
+	fs.Parse(args)
+
+	dist := schema.Distribution{
+		Kind:   *balance,
+		Min:    *balanceMin,
+		Max:    *balanceMax,
+		Mean:   *balanceMean,
+		StdDev: *balanceStdDev,
+	}
+	if err := dist.Validate(); err != nil {
+		return fmt.Errorf("invalid balance distribution: %w", err)
+	}
+
+	db, err := openDB(*dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	if err = schema.Create(ctx, db); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+	log.Printf("created schema")
+
+	inserted, err := schema.SeedWorkload(ctx, db, splitList(*regions))
+	if err != nil {
+		return fmt.Errorf("seeding workload: %w", err)
+	}
+	log.Printf("inserted %d workload rows", inserted)
+
+	inserted, err = schema.SeedAccounts(ctx, db, *accounts, dist)
+	if err != nil {
+		return fmt.Errorf("seeding accounts: %w", err)
+	}
+	log.Printf("inserted %d accounts", inserted)
+
+	return nil
+}
+
+// splitList splits a comma-separated list, ignoring empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+
+	return out
+}
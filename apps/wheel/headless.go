@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+	"github.com/codingconcepts/scale-spin/apps/pkg/wheel"
+)
+
+// runHeadless spins the wheel without a window, using the same physics
+// as the windowed game, then prints and applies the scenario it lands
+// on. Any scenario windows due an update (such as those that expired
+// since the last run) are updated first. Unless detached, it then keeps
+// ramping and reverting windows until none are active, as the windowed
+// game would, so that ramping scenarios take effect.
+func runHeadless(controller *scenario.Controller, catalogue scenario.Catalogue, actor string, detach bool) error {
+	ctx := context.Background()
+
+	if _, err := controller.Tick(ctx); err != nil {
+		return fmt.Errorf("updating scenario windows: %w", err)
+	}
+
+	landed := wheel.New(catalogue.Scenarios).Simulate()
+	fmt.Println(landed)
+
+	if err := controller.Apply(ctx, landed, actor); err != nil {
+		return fmt.Errorf("applying scenario: %w", err)
+	}
+
+	if detach {
+		return nil
+	}
+
+	log.Printf("waiting for scenario windows to settle or expire")
+	if err := controller.Settle(ctx, time.Second*5); err != nil {
+		return fmt.Errorf("updating scenario windows: %w", err)
+	}
+
+	return nil
+}
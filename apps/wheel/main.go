@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"math"
-	"math/rand"
-	"net/http"
 	"os"
+	"time"
 
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
 	"github.com/codingconcepts/scale-spin/apps/pkg/models"
+	"github.com/codingconcepts/scale-spin/apps/pkg/monitor"
+	"github.com/codingconcepts/scale-spin/apps/pkg/round"
+	"github.com/codingconcepts/scale-spin/apps/pkg/scenario"
+	"github.com/codingconcepts/scale-spin/apps/pkg/wheel"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -22,27 +27,27 @@ import (
 )
 
 const (
-	screenW = 640
+	wheelW  = 640
+	panelW  = 260
+	screenW = wheelW + panelW
 	screenH = 640
-)
 
-var (
-	scenarios = []models.Scenario{
-		models.ScenarioScaleUpAP,
-		models.ScenarioScaleDownAP,
-		models.ScenarioScaleUpEU,
-		models.ScenarioScaleDownEU,
-		models.ScenarioScaleUpUS,
-		models.ScenarioScaleDownUS,
-		models.ScenarioFlashSale,
-		models.ScenarioNewProduct,
-		models.ScenarioScandal,
-		models.ScenarioTest,
-	}
+	sparkW = 220
+	sparkH = 36
 )
 
 func main() {
 	dbURL := flag.String("url", "", "url to the database")
+	scenariosPath := flag.String("scenarios", "", "path to a YAML or JSON scenario catalogue (defaults to the built-in scenarios)")
+	weights := flag.String("weights", "", "comma-separated scenario weights overriding the catalogue (e.g. flash-sale=3,test=0)")
+	actor := flag.String("actor", defaultActor(), "name recorded against applied scenarios in the scenario history")
+	apURL := flag.String("ap-url", os.Getenv("AP_SERVICE_URL"), "url of the AP workload service, for live Apdex scores")
+	euURL := flag.String("eu-url", os.Getenv("EU_SERVICE_URL"), "url of the EU workload service, for live Apdex scores")
+	usURL := flag.String("us-url", os.Getenv("US_SERVICE_URL"), "url of the US workload service, for live Apdex scores")
+	roundDuration := flag.Duration("round", time.Minute*10, "how long the database has to scale after each spin (0 to disable rounds)")
+	target := flag.Float64("target", 0.85, "Apdex score the database must average over a round to pass")
+	headless := flag.Bool("headless", false, "spin the wheel once without a window, print the result and apply it")
+	detach := flag.Bool("detach", false, "with --headless, exit as soon as the scenario's window is created rather than waiting for it to settle or expire (ramping scenarios then only move when another wheel ticks their window)")
 	flag.Parse()
 
 	if *dbURL == "" {
@@ -50,67 +55,116 @@ func main() {
 		os.Exit(2)
 	}
 
+	catalogue, err := scenario.LoadCatalogue(*scenariosPath)
+	if err != nil {
+		log.Fatalf("error loading scenarios: %v", err)
+	}
+
+	weightOverrides, err := wheel.ParseWeights(*weights)
+	if err != nil {
+		log.Fatalf("error parsing weights: %v", err)
+	}
+
+	if catalogue, err = catalogue.WithWeights(weightOverrides); err != nil {
+		log.Fatalf("error applying weights: %v", err)
+	}
+
 	db, err := sql.Open("pgx", *dbURL)
 	if err != nil {
 		log.Fatalf("error opening database connection: %v", err)
 	}
 
+	controller := scenario.NewController(db, catalogue)
+
+	if *headless {
+		if err = runHeadless(controller, catalogue, *actor, *detach); err != nil {
+			log.Fatalf("running headless: %v", err)
+		}
+		return
+	}
+
+	go controller.Watch(context.Background(), time.Second*5)
+
+	var mon *monitor.Monitor
+	if services := monitor.Services(*apURL, *euURL, *usURL); len(services) > 0 {
+		mon = monitor.New(services, 60)
+		go mon.Run(context.Background(), time.Second*5)
+	}
+
 	ebiten.SetWindowSize(screenW, screenH)
 	ebiten.SetWindowTitle("Scale Spin")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
-	game := NewGame(db)
+	r := rules{round: *roundDuration, target: *target}
+	game := NewGame(db, controller, catalogue, mon, *actor, r)
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatalf("running game: %v", err)
 	}
 }
 
+func defaultActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "wheel"
+}
+
 type Game struct {
 	db               *sql.DB
-	regionServices   map[string]*http.Client
-	segments         []models.Scenario
+	controller       *scenario.Controller
+	actor            string
+	monitor          *monitor.Monitor
+	wheel            *wheel.Wheel
 	colors           []color.RGBA
-	angle            float64
-	angVel           float64
-	spinning         bool
 	centerX, centerY float64
 	radius           float64
 	lastResult       models.Scenario
 
+	// applying is set while a landed scenario is being applied in the
+	// background, with the outcome delivered over applied.
+	applying bool
+	applied  chan applied
+
+	rules      rules
+	round      *round.Round
+	lastSample time.Time
+	verdict    *round.Verdict
+
 	white1x1 *ebiten.Image
 }
 
-func NewGame(db *sql.DB) *Game {
+func NewGame(db *sql.DB, controller *scenario.Controller, catalogue scenario.Catalogue, mon *monitor.Monitor, actor string, r rules) *Game {
 	white := ebiten.NewImage(1, 1)
 	white.Fill(color.White)
 
 	return &Game{
-		db:       db,
-		segments: scenarios,
-		colors:   palette(len(scenarios)),
-		centerX:  screenW / 2,
-		centerY:  screenH / 2,
-		radius:   260,
-		white1x1: white,
+		db:         db,
+		controller: controller,
+		actor:      actor,
+		monitor:    mon,
+		wheel:      wheel.New(catalogue.Scenarios),
+		colors:     segmentColors(catalogue.Scenarios),
+		centerX:    wheelW / 2,
+		centerY:    screenH / 2,
+		radius:     260,
+		applied:    make(chan applied, 1),
+		rules:      r,
+		white1x1:   white,
 	}
 }
 
 func (g *Game) Update() error {
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && !g.spinning {
-		g.spinning = true
+	g.updateApplied()
+	g.updateRound()
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && !g.wheel.Spinning() && !g.applying && g.round == nil {
 		g.lastResult = ""
-		g.angVel = 0.4 + rand.Float64()*0.6
-		g.angle += rand.Float64() * 2 * math.Pi
-	}
-	if g.spinning {
-		g.angle += g.angVel
-		g.angVel *= 0.97
-		if g.angVel < 0.002 {
-			g.spinning = false
-			g.angVel = 0
-			landed := g.segmentAtPointer()
-			g.lastResult = landed
-		}
+		g.verdict = nil
+		g.wheel.Spin()
+	}
+	if landed, ok := g.wheel.Step(); ok {
+		g.lastResult = landed
+		g.land(landed)
 	}
 	return nil
 }
@@ -119,62 +173,62 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{24, 26, 27, 255})
 	g.drawWheel(screen)
 	g.drawPointer(screen)
+	g.drawApdex(screen)
+	g.drawRound(screen)
 
-	ebitenutil.DebugPrintAt(screen, "Click to spin the wheel", 10, 10)
 	if g.lastResult == "" {
 		return
 	}
 
 	ebitenutil.DebugPrintAt(screen, string(g.lastResult), 10, screenH-20)
+}
 
-	if err := g.applyScenario(g.lastResult); err != nil {
-		log.Printf("publish error: %v", err)
-	}
-
-	g.lastResult = ""
+// applied is the outcome of applying a landed scenario.
+type applied struct {
+	scenario models.Scenario
+	err      error
 }
 
-func (g *Game) applyScenario(s models.Scenario) error {
-	log.Printf("publishing scenario: %s...", s)
+// land applies the scenario the wheel landed on in the background, so a
+// slow database doesn't freeze the wheel.
+func (g *Game) land(s models.Scenario) {
+	g.applying = true
 
-	switch s {
-	case models.ScenarioScaleUpAP:
-		return g.updateDB(1, models.RegionAP)
-	case models.ScenarioScaleDownAP:
-		return g.updateDB(-1, models.RegionAP)
-
-	case models.ScenarioScaleUpEU:
-		return g.updateDB(1, models.RegionEU)
-	case models.ScenarioScaleDownEU:
-		return g.updateDB(-1, models.RegionEU)
-
-	case models.ScenarioScaleUpUS:
-		return g.updateDB(1, models.RegionUS)
-	case models.ScenarioScaleDownUS:
-		return g.updateDB(-1, models.RegionUS)
-
-	case models.ScenarioNewProduct:
-		return g.updateDB(5, models.RegionAP, models.RegionEU, models.RegionUS)
-	case models.ScenarioFlashSale:
-		return g.updateDB(10, models.RegionAP, models.RegionEU, models.RegionUS)
-	case models.ScenarioScandal:
-		return g.updateDB(5, models.RegionAP, models.RegionEU, models.RegionUS)
+	go func() {
+		g.applied <- applied{scenario: s, err: g.applyScenario(s)}
+	}()
+}
 
+// updateApplied starts a round once a landed scenario has been applied.
+// Rounds are judged on Apdex scores, so they're only played when scores
+// are being monitored.
+func (g *Game) updateApplied() {
+	var a applied
+	select {
+	case a = <-g.applied:
 	default:
-		return fmt.Errorf("unsupported scenario: %s", s)
+		return
 	}
-}
 
-func (g *Game) updateDB(delta int, regions ...string) error {
-	const stmt = `UPDATE workload
-								SET workers = GREATEST(workers + $1, 0)
-								WHERE region = ANY($2)`
+	g.applying = false
+	if a.err != nil {
+		log.Printf("publish error: %v", a.err)
+		return
+	}
 
-	if _, err := g.db.Exec(stmt, delta, regions); err != nil {
-		return fmt.Errorf("making request: %w", err)
+	if g.monitor != nil && g.rules.round > 0 {
+		g.round = round.New(a.scenario, g.rules.round, g.rules.target)
+		g.lastSample = g.round.StartedAt
 	}
+}
 
-	return nil
+func (g *Game) applyScenario(s models.Scenario) error {
+	log.Printf("publishing scenario: %s...", s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	return g.controller.Apply(ctx, s, g.actor)
 }
 
 func (g *Game) Layout(_, _ int) (int, int) {
@@ -182,33 +236,42 @@ func (g *Game) Layout(_, _ int) (int, int) {
 }
 
 func (g *Game) drawWheel(screen *ebiten.Image) {
-	n := len(g.segments)
+	segments := g.wheel.Segments()
+	bounds := g.wheel.Bounds()
+	angle := g.wheel.Angle()
+
+	n := len(segments)
 	if n == 0 {
 		return
 	}
-	anglePer := 2 * math.Pi / float64(n)
 
 	// Colored wedges
 	for i := range n {
-		start := float64(i)*anglePer + g.angle
-		end := float64(i+1)*anglePer + g.angle
+		start := bounds[i] + angle
+		end := bounds[i+1] + angle
+		if end == start {
+			continue
+		}
 		g.drawWedge(screen, g.centerX, g.centerY, g.radius, start, end, g.colors[i])
 	}
 
 	// Labels
 	face := basicfont.Face7x13
 	for i := range n {
-		mid := float64(i)*anglePer + anglePer/2 + g.angle
+		if bounds[i+1] == bounds[i] {
+			continue
+		}
+		mid := (bounds[i]+bounds[i+1])/2 + angle
 		r := g.radius * 0.62
 		tx := int(g.centerX + r*math.Cos(mid))
 		ty := int(g.centerY + r*math.Sin(mid))
-		label := g.segments[i]
-		b := text.BoundString(face, string(label))
-		text.Draw(screen, string(label), face, tx-b.Dx()/2, ty+b.Dy()/2, color.Black)
+		label := segments[i].Label
+		b := text.BoundString(face, label)
+		text.Draw(screen, label, face, tx-b.Dx()/2, ty+b.Dy()/2, color.Black)
 	}
 
 	for i := range n {
-		a := float64(i)*anglePer + g.angle
+		a := bounds[i] + angle
 		x2 := g.centerX + g.radius*math.Cos(a)
 		y2 := g.centerY + g.radius*math.Sin(a)
 		ebitenutil.DrawLine(screen, g.centerX, g.centerY, x2, y2, color.RGBA{0, 0, 0, 120})
@@ -218,6 +281,82 @@ func (g *Game) drawWheel(screen *ebiten.Image) {
 	g.drawDisk(screen, g.centerX, g.centerY, hubR, color.RGBA{230, 230, 230, 255})
 }
 
+// globalWindow is the window over which the global Apdex score is
+// shown.
+const globalWindow = "1m"
+
+// drawApdex draws each region's latest Apdex score, grade and a sparkline
+// of recent scores in the panel next to the wheel.
+func (g *Game) drawApdex(screen *ebiten.Image) {
+	if g.monitor == nil {
+		return
+	}
+
+	face := basicfont.Face7x13
+	x := wheelW + 10
+	y := 40
+
+	ebitenutil.DebugPrintAt(screen, "Apdex", x, 10)
+
+	for _, r := range g.monitor.Regions() {
+		ebitenutil.DebugPrintAt(screen, r.Name, x, y)
+
+		status, c := "waiting...", color.RGBA{160, 160, 160, 255}
+		if !r.At.IsZero() {
+			grade := apdex.Grade(r.Score)
+			status, c = fmt.Sprintf("%.2f %s", r.Score, grade), gradeColor(grade)
+		}
+		if r.Err != nil {
+			status += " (unavailable)"
+		}
+		text.Draw(screen, status, face, x, y+30, c)
+
+		g.drawSparkline(screen, r.History, float64(x), float64(y+38))
+		y += 100
+	}
+
+	// Weighted by each region's requests, rather than averaging scores.
+	global := g.monitor.Global(globalWindow)
+	if global.Samples() > 0 {
+		grade := apdex.Grade(global.Score())
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Global (%s)", globalWindow), x, y)
+		text.Draw(screen, fmt.Sprintf("%.2f %s", global.Score(), grade), face, x, y+30, gradeColor(grade))
+	}
+}
+
+// drawSparkline draws scores between 0 and 1 as a line within a box.
+func (g *Game) drawSparkline(screen *ebiten.Image, scores []float64, x, y float64) {
+	border := color.RGBA{80, 80, 80, 255}
+	ebitenutil.DrawLine(screen, x, y, x+sparkW, y, border)
+	ebitenutil.DrawLine(screen, x, y+sparkH, x+sparkW, y+sparkH, border)
+
+	if len(scores) < 2 {
+		return
+	}
+
+	step := sparkW / float64(len(scores)-1)
+	for i := 1; i < len(scores); i++ {
+		x1, y1 := x+float64(i-1)*step, y+sparkH*(1-scores[i-1])
+		x2, y2 := x+float64(i)*step, y+sparkH*(1-scores[i])
+		ebitenutil.DrawLine(screen, x1, y1, x2, y2, gradeColor(apdex.Grade(scores[i])))
+	}
+}
+
+func gradeColor(grade string) color.RGBA {
+	switch grade {
+	case "Excellent":
+		return color.RGBA{76, 175, 80, 255}
+	case "Good":
+		return color.RGBA{139, 195, 74, 255}
+	case "Fair":
+		return color.RGBA{255, 193, 7, 255}
+	case "Poor":
+		return color.RGBA{255, 152, 0, 255}
+	default:
+		return color.RGBA{244, 67, 54, 255}
+	}
+}
+
 func (g *Game) drawPointer(screen *ebiten.Image) {
 	pW := 30.0
 	pH := 36.0
@@ -277,21 +416,16 @@ func (g *Game) drawDisk(screen *ebiten.Image, cx, cy, r float64, col color.RGBA)
 	screen.DrawTriangles(verts, idx, g.white1x1, nil)
 }
 
-func (g *Game) segmentAtPointer() models.Scenario {
-	n := len(g.segments)
-	if n == 0 {
-		return ""
-	}
-	segAngle := 2 * math.Pi / float64(n)
-	a := math.Mod(-math.Pi/2-g.angle, 2*math.Pi)
-	if a < 0 {
-		a += 2 * math.Pi
-	}
-	idx := max(int(math.Floor(a/segAngle)), 0)
-	if idx >= n {
-		idx = n - 1
+// segmentColors returns each segment's configured colour, falling back
+// to the palette for any segment without one.
+func segmentColors(segments []scenario.Definition) []color.RGBA {
+	out := palette(len(segments))
+	for i, s := range segments {
+		if c, ok := s.Color(); ok {
+			out[i] = c
+		}
 	}
-	return g.segments[idx]
+	return out
 }
 
 func palette(n int) []color.RGBA {
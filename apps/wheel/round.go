@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/codingconcepts/scale-spin/apps/pkg/round"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// rules configure the round that follows each spin.
+type rules struct {
+	round  time.Duration
+	target float64
+}
+
+// roundWindow is the Apdex window sampled during a round. It's sampled
+// about once per window, so each request counts towards the round about
+// once. Windows are snapshots taken when the monitor polls (every 5s),
+// so requests near a poll can be sampled twice or missed, and the
+// weighting is approximate.
+const (
+	roundWindow      = "10s"
+	roundSampleEvery = time.Second * 10
+)
+
+// updateRound samples the global Apdex score once per window and, once
+// the round is over, judges and stores its verdict.
+func (g *Game) updateRound() {
+	if g.round == nil {
+		return
+	}
+
+	if time.Since(g.lastSample) >= roundSampleEvery {
+		g.round.Sample(g.monitor.Global(roundWindow))
+		g.lastSample = time.Now()
+	}
+
+	if !g.round.Done() {
+		return
+	}
+
+	v := g.round.Verdict()
+	g.verdict = &v
+	g.round = nil
+
+	log.Printf("round over: %s scored %.2f (target %.2f, passed %t)", v.Scenario, v.Score, v.Target, v.Passed)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if err := round.Save(ctx, g.db, v); err != nil {
+			log.Printf("error saving round: %v", err)
+		}
+	}()
+}
+
+func (g *Game) drawRound(screen *ebiten.Image) {
+	face := basicfont.Face7x13
+
+	switch {
+	case g.applying:
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Applying %s...", g.lastResult), 10, 10)
+
+	case g.round != nil:
+		remaining := g.round.Remaining().Round(time.Second)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Round: %s", g.round.Scenario), 10, 10)
+		text.Draw(screen, fmt.Sprintf("%02d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60), face, 10, 44, color.White)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Score %.2f / target %.2f", g.round.Score(), g.round.Target), 10, 50)
+
+	case g.verdict != nil:
+		result, c := "FAIL", gradeColor("Unacceptable")
+		if g.verdict.Passed {
+			result, c = "PASS", gradeColor("Excellent")
+		}
+		text.Draw(screen, result, face, 10, 44, c)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Scored %.2f / target %.2f", g.verdict.Score, g.verdict.Target), 10, 50)
+		ebitenutil.DebugPrintAt(screen, "Click to spin the wheel", 10, 10)
+
+	default:
+		ebitenutil.DebugPrintAt(screen, "Click to spin the wheel", 10, 10)
+	}
+}
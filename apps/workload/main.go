@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/codingconcepts/env"
+	"github.com/codingconcepts/scale-spin/apps/pkg/apdex"
+	"github.com/codingconcepts/scale-spin/apps/pkg/invariant"
 	"github.com/codingconcepts/scale-spin/apps/pkg/repo"
 	"github.com/codingconcepts/scale-spin/apps/pkg/runner"
 
@@ -13,9 +17,16 @@ import (
 )
 
 type environment struct {
-	DatabaseDriver string `env:"DATABASE_DRIVER" required:"true"`
-	DatabaseURL    string `env:"DATABASE_URL" required:"true"`
-	Region         string `env:"REGION" required:"true"`
+	DatabaseDriver    string        `env:"DATABASE_DRIVER" required:"true"`
+	DatabaseURL       string        `env:"DATABASE_URL" required:"true"`
+	Region            string        `env:"REGION" required:"true"`
+	RepoMode          string        `env:"REPO_MODE" default:"single-region"`
+	LoadMode          string        `env:"LOAD_MODE" default:"closed"`
+	ApdexModel        string        `env:"APDEX_MODEL" default:"tiered"`
+	ApdexT            time.Duration `env:"APDEX_T" default:"50ms"`
+	ApdexBuckets      string        `env:"APDEX_BUCKETS"`
+	MaxRetries        int           `env:"MAX_RETRIES" default:"5"`
+	InvariantInterval time.Duration `env:"INVARIANT_INTERVAL" default:"30s"`
 }
 
 func main() {
@@ -29,14 +40,33 @@ func main() {
 		log.Fatalf("error connecting to database: %v", err)
 	}
 
-	var r repo.Repo
-	switch strings.ToLower(e.DatabaseDriver) {
-	case "pgx":
-		r = repo.NewPostgresRepo(db)
-		// r = repo.NewPostgresRepoMR(db, e.Region)
+	retry := repo.DefaultRetryPolicy
+	retry.MaxRetries = e.MaxRetries
+
+	r, err := repo.New(strings.ToLower(e.RepoMode), repo.Config{DB: db, Region: e.Region, Retry: retry})
+	if err != nil {
+		log.Fatalf("error creating repo: %v", err)
+	}
+	log.Printf("repo mode: %s", e.RepoMode)
+
+	mode, err := runner.ParseLoadMode(e.LoadMode)
+	if err != nil {
+		log.Fatalf("error parsing load mode: %v", err)
 	}
 
-	runner := runner.New(r, e.Region)
+	model, err := apdex.ParseModel(e.ApdexModel, e.ApdexT, e.ApdexBuckets)
+	if err != nil {
+		log.Fatalf("error parsing apdex model: %v", err)
+	}
+	log.Printf("apdex model: %s", model)
+
+	runner := runner.New(r, strings.ToLower(e.RepoMode), e.Region, mode, model)
+
+	if e.InvariantInterval > 0 {
+		checker := invariant.NewChecker(db)
+		runner.Handle("GET /invariant", checker.Handler())
+		go checker.Run(context.Background(), e.InvariantInterval)
+	}
 
 	go runner.Serve()
 	runner.Run()
@@ -10,9 +10,9 @@ require (
 	github.com/codingconcepts/errhandler v0.0.6
 	github.com/hajimehoshi/ebiten/v2 v2.9.1
 	github.com/jackc/pgx/v5 v5.7.6
-	github.com/samber/lo v1.52.0
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/image v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -42,5 +42,4 @@ require (
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )